@@ -0,0 +1,187 @@
+// Package cache stores the DsDoc batches extracted from source files, so that
+// files which have not changed since the last run are not read again.
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/butlermatt/dsdoc/trim"
+)
+
+// File is the default name of the cache file.
+const File = ".dsdoc-cache"
+
+// format is the version of the cache file layout. It is incremented whenever
+// the cached batches change, so that builds of the tool made between
+// releases do not read entries they cannot use.
+const format = 1
+
+// entry is the cached state of a single source file.
+type entry struct {
+	Size    int64
+	ModTime int64
+	Hash    [sha256.Size]byte
+	// Syntax is the comment syntax the batches were extracted with.
+	Syntax  trim.Syntax
+	Batches []trim.Batch
+}
+
+// data is the content of the cache file.
+type data struct {
+	Format  int
+	Version string
+	Entries map[string]*entry
+}
+
+// Cache holds the batches of the files scanned. It is safe for concurrent
+// use.
+type Cache struct {
+	path string
+	mu   sync.Mutex
+	data data
+	// seen holds the paths looked up since the cache was opened. Only these
+	// are saved, so deleted files are dropped.
+	seen map[string]bool
+	// Hits and Misses count the lookups which did and did not use the cache.
+	Hits, Misses int
+}
+
+// Open reads the cache file at path. A missing or unreadable file, or one
+// written by another version of the tool, results in an empty cache.
+func Open(path, version string) *Cache {
+	c := &Cache{
+		path: path,
+		data: data{Format: format, Version: version, Entries: make(map[string]*entry)},
+		seen: make(map[string]bool),
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	var d data
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&d); err != nil || d.Format != format || d.Version != version || d.Entries == nil {
+		return c
+	}
+	c.data = d
+	return c
+}
+
+// Trim returns the batches of the source file at path, which is reported as
+// name, extracted with syn. If the size and modification time of the file,
+// or failing that the hash of its content, match the cache the file is not
+// trimmed again.
+func (c *Cache) Trim(path, name string, syn trim.Syntax) ([]trim.Batch, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	e := c.data.Entries[path]
+	c.seen[path] = true
+	c.mu.Unlock()
+
+	if e != nil && e.Size == info.Size() && e.ModTime == info.ModTime().UnixNano() && reflect.DeepEqual(e.Syntax, syn) {
+		c.count(true)
+		return rename(e.Batches, name), nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(b)
+	hit := e != nil && e.Hash == hash && reflect.DeepEqual(e.Syntax, syn)
+
+	ne := &entry{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Hash: hash, Syntax: syn}
+	if hit {
+		ne.Batches = e.Batches
+	} else {
+		ne.Batches = trim.Trim(strings.Split(string(b), "\n"), name, syn)
+	}
+
+	c.mu.Lock()
+	c.data.Entries[path] = ne
+	c.mu.Unlock()
+	c.count(hit)
+
+	return rename(ne.Batches, name), nil
+}
+
+func (c *Cache) count(hit bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hit {
+		c.Hits++
+	} else {
+		c.Misses++
+	}
+}
+
+// rename returns bs with the file name of each batch set to name.
+func rename(bs []trim.Batch, name string) []trim.Batch {
+	if len(bs) == 0 || bs[0].File == name {
+		return bs
+	}
+	r := make([]trim.Batch, len(bs))
+	for i, b := range bs {
+		b.File = name
+		r[i] = b
+	}
+	return r
+}
+
+// Save writes the entries of the files looked up since the cache was opened
+// to the cache file.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	d := data{Format: format, Version: c.data.Version, Entries: make(map[string]*entry)}
+	for p := range c.seen {
+		if e := c.data.Entries[p]; e != nil {
+			d.Entries[p] = e
+		}
+	}
+	c.mu.Unlock()
+
+	var b bytes.Buffer
+	if err := gob.NewEncoder(&b).Encode(&d); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so an interrupted run cannot leave a
+	// corrupt cache.
+	tmp, err := ioutil.TempFile(filepath.Dir(c.path), filepath.Base(c.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(b.Bytes()); err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// Clean removes the cache file at path. It is not an error if there is none.
+func Clean(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
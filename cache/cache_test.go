@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/butlermatt/dsdoc/trim"
+)
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "device.dart")
+	other := filepath.Join(dir, "other.dart")
+	cf := filepath.Join(dir, File)
+	write := func(p, data string, mod time.Time) {
+		if err := ioutil.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	syn, _ := trim.Lookup(src)
+	mod := time.Now().Add(-time.Hour)
+	write(src, "//* @Node device\n//* @Parent root\n", mod)
+	write(other, "//* @Node other\n", mod)
+
+	// run opens the cache, trims src as name and saves the cache.
+	run := func(version, name string, syn trim.Syntax) (*Cache, []trim.Batch) {
+		c := Open(cf, version)
+		bs, err := c.Trim(src, name, syn)
+		if err != nil {
+			t.Fatalf("Unexpected error %q", err)
+		}
+		if err := c.Save(); err != nil {
+			t.Fatalf("Unexpected save error %q", err)
+		}
+		return c, bs
+	}
+	check := func(step string, c *Cache, bs []trim.Batch, hits int, name, first string) {
+		if c.Hits != hits || c.Hits+c.Misses != 1 {
+			t.Errorf("%s. Hits mismatch: exp=%d got=%d misses=%d", step, hits, c.Hits, c.Misses)
+		}
+		if len(bs) != 1 || bs[0].File != name || bs[0].Lines[0] != first {
+			t.Errorf("%s. Batches mismatch: %+v", step, bs)
+		}
+	}
+
+	c, bs := run("1", "device.dart", syn)
+	check("first run", c, bs, 0, "device.dart", "@Node device")
+
+	c, bs = run("1", "device.dart", syn)
+	check("unchanged", c, bs, 1, "device.dart", "@Node device")
+
+	c, bs = run("1", "lib/device.dart", syn)
+	check("renamed", c, bs, 1, "lib/device.dart", "@Node device")
+
+	// Touching a file without changing it matches by hash.
+	write(src, "//* @Node device\n//* @Parent root\n", mod.Add(time.Minute))
+	c, bs = run("1", "device.dart", syn)
+	check("touched", c, bs, 1, "device.dart", "@Node device")
+
+	write(src, "//* @Node changed\n//* @Parent root\n", mod.Add(2*time.Minute))
+	c, bs = run("1", "device.dart", syn)
+	check("changed", c, bs, 0, "device.dart", "@Node changed")
+
+	c, bs = run("2", "device.dart", syn)
+	check("new version", c, bs, 0, "device.dart", "@Node changed")
+
+	c, bs = run("2", "device.dart", trim.Syntax{Line: "//*", Comment: "//"})
+	check("new syntax", c, bs, 0, "device.dart", "@Node changed")
+
+	// Only the files looked up are saved.
+	c = Open(cf, "2")
+	if _, err := c.Trim(other, "other.dart", syn); err != nil {
+		t.Fatal(err)
+	}
+	c.Save()
+	c = Open(cf, "2")
+	if len(c.data.Entries) != 1 || c.data.Entries[other] == nil {
+		t.Errorf("Expected only %s to be cached, found %v", other, c.data.Entries)
+	}
+
+	if _, err := c.Trim(filepath.Join(dir, "missing.dart"), "missing.dart", syn); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+
+	if err := Clean(cf); err != nil {
+		t.Errorf("Unexpected clean error %q", err)
+	}
+	if _, err := os.Stat(cf); !os.IsNotExist(err) {
+		t.Errorf("Expected the cache file to be removed, got %v", err)
+	}
+	if err := Clean(cf); err != nil {
+		t.Errorf("Unexpected error cleaning a missing cache %q", err)
+	}
+}
+
+func TestOpen_Corrupt(t *testing.T) {
+	f, err := ioutil.TempFile("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("not a cache")
+	f.Close()
+
+	c := Open(f.Name(), "1")
+	if len(c.data.Entries) != 0 {
+		t.Errorf("Expected an empty cache, found %v", c.data.Entries)
+	}
+}
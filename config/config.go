@@ -0,0 +1,313 @@
+// Package config reads the project configuration file, dsdoc.yaml or
+// dsdoc.toml, which holds the settings otherwise given as flags.
+//
+// A dsdoc.yaml file may also declare DsDocs with the link, nodes and actions
+// keys read by package decl. A TOML configuration may only set the link.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/butlermatt/dsdoc/parser"
+	"github.com/butlermatt/dsdoc/trim"
+)
+
+// Names lists the names a configuration file is found by, in order of
+// preference.
+var Names = []string{"dsdoc.yaml", "dsdoc.yml", "dsdoc.toml"}
+
+// Strictness levels decide which diagnostics prevent documentation from
+// being generated.
+const (
+	// StrictErrors fails on errors only.
+	StrictErrors = "errors"
+	// StrictWarnings fails on warnings as well as errors.
+	StrictWarnings = "warnings"
+)
+
+// Config holds the settings of a project.
+type Config struct {
+	// File is the path the configuration was read from, "" if none.
+	File string `yaml:"-" toml:"-"`
+	// Outputs lists the files to generate.
+	Outputs []Output `yaml:"outputs" toml:"outputs"`
+	// Inputs lists the files and directories to scan.
+	Inputs []string `yaml:"inputs" toml:"inputs"`
+	// Include and Exclude are the globs given to -include and -exclude.
+	Include []string `yaml:"include,omitempty" toml:"include"`
+	Exclude []string `yaml:"exclude,omitempty" toml:"exclude"`
+	// GitIgnore is true if .gitignore files are honoured.
+	GitIgnore bool `yaml:"gitignore" toml:"gitignore"`
+	// Syntax maps file extensions to their DsDoc comment syntax, either the
+	// extension of a known language or a table of the fields of trim.Syntax.
+	Syntax map[string]interface{} `yaml:"syntax,omitempty" toml:"syntax"`
+	// Sort is the order children are listed in.
+	Sort string `yaml:"sort" toml:"sort"`
+	// Strict is the strictness level, errors or warnings.
+	Strict string `yaml:"strict" toml:"strict"`
+	// Jobs is the number of files scanned in parallel, 0 for one per CPU.
+	Jobs int `yaml:"jobs,omitempty" toml:"jobs"`
+	// Link sets the link metadata not given by a @Link DsDoc.
+	Link Link `yaml:"link,omitempty" toml:"link"`
+
+	// Nodes and Actions are read by package decl.
+	Nodes   interface{} `yaml:"nodes,omitempty" toml:"-"`
+	Actions interface{} `yaml:"actions,omitempty" toml:"-"`
+}
+
+// Output is a file to generate.
+type Output struct {
+	// Format is the name of a built in output type. Template is the path of
+	// a template file. Exactly one of the two is set.
+	Format   string `yaml:"format,omitempty" toml:"format"`
+	Template string `yaml:"template,omitempty" toml:"template"`
+	// Path is the file to write, by default api with the extension of the
+	// output.
+	Path string `yaml:"path,omitempty" toml:"path"`
+}
+
+// Link is the metadata of the link.
+type Link struct {
+	Name     string `yaml:"name,omitempty" toml:"name"`
+	Version  string `yaml:"version,omitempty" toml:"version"`
+	Author   string `yaml:"author,omitempty" toml:"author"`
+	License  string `yaml:"license,omitempty" toml:"license"`
+	Homepage string `yaml:"homepage,omitempty" toml:"homepage"`
+	Short    string `yaml:"short,omitempty" toml:"short"`
+	Long     string `yaml:"long,omitempty" toml:"long"`
+}
+
+// Default returns the settings used when neither a configuration file nor a
+// flag sets them.
+func Default() *Config {
+	return &Config{
+		Outputs: []Output{{Format: "md"}},
+		Inputs:  []string{"."},
+		Sort:    parser.ActionsFirst.String(),
+		Strict:  StrictErrors,
+	}
+}
+
+// Find returns the path of the configuration file in dir, or "" if there is
+// none.
+func Find(dir string) string {
+	for _, n := range Names {
+		p := filepath.Join(dir, n)
+		if info, err := os.Stat(p); err == nil && info.Mode().IsRegular() {
+			return p
+		}
+	}
+	return ""
+}
+
+// Load reads the configuration file at path over the Default settings. Files
+// with a .toml extension are read as TOML, others as YAML. Relative paths are
+// resolved against the directory of the file.
+func Load(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := Default()
+	if strings.ToLower(filepath.Ext(path)) == ".toml" {
+		md, err := toml.Decode(string(data), c)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		for _, k := range md.Undecoded() {
+			if len(k) > 1 && k[0] == "syntax" {
+				continue
+			}
+			return nil, fmt.Errorf("%s: unknown setting %q", path, k.String())
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	c.File = path
+
+	if dir := filepath.Dir(path); dir != "." {
+		for i, in := range c.Inputs {
+			c.Inputs[i] = resolve(dir, in)
+		}
+		for i := range c.Outputs {
+			c.Outputs[i].Template = resolve(dir, c.Outputs[i].Template)
+			c.Outputs[i].Path = resolve(dir, c.Outputs[i].Path)
+		}
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return c, nil
+}
+
+func resolve(dir, p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
+
+// Validate checks the values of the settings.
+func (c *Config) Validate() error {
+	if _, err := parser.ParseSortOrder(c.Sort); err != nil {
+		return err
+	}
+	if c.Strict != StrictErrors && c.Strict != StrictWarnings {
+		return fmt.Errorf("unknown strictness %q, expected %s or %s", c.Strict, StrictErrors, StrictWarnings)
+	}
+	if len(c.Outputs) == 0 {
+		return fmt.Errorf("no outputs")
+	}
+	for i, o := range c.Outputs {
+		if (o.Format == "") == (o.Template == "") {
+			return fmt.Errorf("output %d must set exactly one of format or template", i+1)
+		}
+	}
+	if len(c.Inputs) == 0 {
+		return fmt.Errorf("no inputs")
+	}
+	if c.Jobs < 0 {
+		return fmt.Errorf("jobs must not be negative")
+	}
+	_, err := c.Syntaxes()
+	return err
+}
+
+// Syntaxes returns the comment syntax of each extension in Syntax.
+func (c *Config) Syntaxes() (map[string]trim.Syntax, error) {
+	r := make(map[string]trim.Syntax)
+	for ext, v := range c.Syntax {
+		if !strings.HasPrefix(ext, ".") {
+			return nil, fmt.Errorf("syntax extension %q must start with a dot", ext)
+		}
+		var syn trim.Syntax
+		switch v := v.(type) {
+		case string:
+			s, ok := trim.Syntaxes[v]
+			if !ok {
+				return nil, fmt.Errorf("syntax for %s: unknown extension %q", ext, v)
+			}
+			syn = s
+		case map[string]interface{}:
+			var err error
+			if syn, err = syntaxTable(v); err != nil {
+				return nil, fmt.Errorf("syntax for %s: %v", ext, err)
+			}
+		default:
+			return nil, fmt.Errorf("syntax for %s must be an extension or a table", ext)
+		}
+		r[strings.ToLower(ext)] = syn
+	}
+	return r, nil
+}
+
+// syntaxTable returns the syntax described by the table t. The key like
+// names an extension whose syntax the other keys override.
+func syntaxTable(t map[string]interface{}) (trim.Syntax, error) {
+	var syn trim.Syntax
+	if like, ok := t["like"].(string); ok {
+		s, ok := trim.Syntaxes[like]
+		if !ok {
+			return syn, fmt.Errorf("unknown extension %q", like)
+		}
+		syn = s
+	}
+
+	fields := map[string]*string{
+		"line":         &syn.Line,
+		"start":        &syn.Start,
+		"end":          &syn.End,
+		"comment":      &syn.Comment,
+		"commentStart": &syn.CommentStart,
+		"commentEnd":   &syn.CommentEnd,
+	}
+	var keys []string
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if k == "like" {
+			continue
+		}
+		f, ok := fields[k]
+		if !ok {
+			return syn, fmt.Errorf("unknown key %q", k)
+		}
+		s, ok := t[k].(string)
+		if !ok {
+			return syn, fmt.Errorf("%s must be a string", k)
+		}
+		*f = s
+	}
+
+	if syn.Line == "" && syn.Start == "" {
+		return syn, fmt.Errorf("one of line or start must be set")
+	}
+	if (syn.Start == "") != (syn.End == "") {
+		return syn, fmt.Errorf("start and end must be set together")
+	}
+	return syn, nil
+}
+
+// Apply sets the link metadata of root which is not already set. If root is
+// not a @Link DsDoc and a link name is configured, root becomes the link,
+// keeping its descriptions unless the link sets them, and problems with it
+// are reported at file, the configuration file. Otherwise there is no link
+// to apply the metadata to and root is left unchanged.
+func (l Link) Apply(root *parser.Document, file string) {
+	if root.Type != parser.LinkDoc {
+		if l.Name == "" {
+			return
+		}
+		root.Type = parser.LinkDoc
+		root.Name = l.Name
+		root.Pos = parser.Position{File: file}
+		if l.Short != "" {
+			root.Short = ""
+		}
+		if l.Long != "" {
+			root.Long = nil
+		}
+	}
+	set := func(dst *string, v string) {
+		if *dst == "" {
+			*dst = v
+		}
+	}
+	set(&root.Version, l.Version)
+	set(&root.Author, l.Author)
+	set(&root.License, l.License)
+	set(&root.Homepage, l.Homepage)
+	set(&root.Short, l.Short)
+	if len(root.Long) == 0 {
+		root.Long = parser.ParseBlocks(l.Long)
+	}
+}
+
+// Print writes the settings to w as YAML. Declared DsDocs are omitted.
+func (c *Config) Print(w io.Writer) error {
+	p := *c
+	p.Nodes, p.Actions = nil, nil
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&p); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/butlermatt/dsdoc/decl"
+	"github.com/butlermatt/dsdoc/parser"
+	"github.com/butlermatt/dsdoc/trim"
+)
+
+// writeFile writes data to name in a new temporary directory, and returns
+// the path and a function to remove the directory.
+func writeFile(t *testing.T, name, data string) (string, func()) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(p, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p, func() { os.RemoveAll(dir) }
+}
+
+const yamlConfig = `outputs:
+  - format: md
+    path: docs/api.md
+  - template: wiki.md.tmpl
+inputs: [lib, config]
+exclude: ["*.g.dart"]
+gitignore: true
+syntax:
+  .kts: .kt
+  .tmpl:
+    line: "{{/*"
+sort: alpha
+strict: warnings
+link:
+  name: Example_Link
+  version: 1.2.0
+nodes:
+  - path: device
+    parent: root
+`
+
+const tomlConfig = `inputs = ["lib", "config"]
+exclude = ["*.g.dart"]
+gitignore = true
+sort = "alpha"
+strict = "warnings"
+
+[[outputs]]
+format = "md"
+path = "docs/api.md"
+
+[[outputs]]
+template = "wiki.md.tmpl"
+
+[syntax]
+".kts" = ".kt"
+
+[syntax.".tmpl"]
+line = "{{/*"
+
+[link]
+name = "Example_Link"
+version = "1.2.0"
+`
+
+func TestLoad(t *testing.T) {
+	for name, src := range map[string]string{"dsdoc.yaml": yamlConfig, "dsdoc.toml": tomlConfig} {
+		path, done := writeFile(t, name, src)
+		defer done()
+		dir := filepath.Dir(path)
+
+		c, err := Load(path)
+		if err != nil {
+			t.Fatalf("%s. Unexpected error %q", name, err)
+		}
+		if c.File != path {
+			t.Errorf("%s. File mismatch: got=%q", name, c.File)
+		}
+		exp := []Output{
+			{Format: "md", Path: filepath.Join(dir, "docs/api.md")},
+			{Template: filepath.Join(dir, "wiki.md.tmpl")},
+		}
+		if len(c.Outputs) != len(exp) || c.Outputs[0] != exp[0] || c.Outputs[1] != exp[1] {
+			t.Errorf("%s. Outputs mismatch: exp=%v got=%v", name, exp, c.Outputs)
+		}
+		if strings.Join(c.Inputs, ",") != filepath.Join(dir, "lib")+","+filepath.Join(dir, "config") {
+			t.Errorf("%s. Inputs mismatch: got=%v", name, c.Inputs)
+		}
+		if len(c.Exclude) != 1 || !c.GitIgnore || c.Sort != "alpha" || c.Strict != StrictWarnings {
+			t.Errorf("%s. Settings mismatch: %+v", name, c)
+		}
+		if c.Link.Name != "Example_Link" || c.Link.Version != "1.2.0" {
+			t.Errorf("%s. Link mismatch: %+v", name, c.Link)
+		}
+
+		syns, err := c.Syntaxes()
+		if err != nil {
+			t.Fatalf("%s. Unexpected syntax error %q", name, err)
+		}
+		if syns[".kts"].Line != trim.Prefix || syns[".kts"].Start != "/***" {
+			t.Errorf("%s. .kts syntax mismatch: %+v", name, syns[".kts"])
+		}
+		if syns[".tmpl"].Line != "{{/*" || syns[".tmpl"].Start != "" {
+			t.Errorf("%s. .tmpl syntax mismatch: %+v", name, syns[".tmpl"])
+		}
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	var tests = []struct {
+		name string
+		src  string
+		err  string
+	}{
+		{name: "dsdoc.yaml", src: "output: []", err: "field output not found"},
+		{name: "dsdoc.toml", src: "output = []", err: `unknown setting "output"`},
+		{name: "dsdoc.yaml", src: "sort: random", err: "random"},
+		{name: "dsdoc.yaml", src: "strict: always", err: "unknown strictness"},
+		{name: "dsdoc.yaml", src: "outputs: [{path: a.md}]", err: "exactly one of format or template"},
+		{name: "dsdoc.yaml", src: "outputs: []", err: "no outputs"},
+		{name: "dsdoc.yaml", src: "syntax: {kts: .kt}", err: "must start with a dot"},
+		{name: "dsdoc.yaml", src: "syntax: {.kts: .none}", err: "unknown extension"},
+		{name: "dsdoc.yaml", src: "syntax: {.x: {start: '/**'}}", err: "start and end"},
+		{name: "dsdoc.yaml", src: "syntax: {.x: {quote: '\"'}}", err: "unknown key"},
+		{name: "dsdoc.yaml", src: "syntax: {.x: {like: .sql}}", err: ""},
+	}
+
+	for _, tt := range tests {
+		path, done := writeFile(t, tt.name, tt.src)
+		_, err := Load(path)
+		done()
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%q. Unexpected error %q", tt.src, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q. Error mismatch: exp=%q got=%v", tt.src, tt.err, err)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	path, done := writeFile(t, "dsdoc.toml", "")
+	defer done()
+	if got := Find(filepath.Dir(path)); got != path {
+		t.Errorf("Find mismatch: exp=%q got=%q", path, got)
+	}
+	if got := Find(filepath.Join(filepath.Dir(path), "none")); got != "" {
+		t.Errorf("Expected no configuration, got %q", got)
+	}
+}
+
+func TestLink_Apply(t *testing.T) {
+	l := Link{Name: "Example_Link", Version: "1.2.0", Short: "An example."}
+
+	p := parser.NewParser()
+	doc, _ := p.Build()
+	l.Apply(doc, "dsdoc.yaml")
+	if doc.Type != parser.LinkDoc || doc.Name != "Example_Link" || doc.Version != "1.2.0" || doc.Short != "An example." {
+		t.Errorf("Root mismatch: %+v", doc)
+	}
+	if doc.Pos.String() != "dsdoc.yaml" {
+		t.Errorf("Position mismatch: %v", doc.Pos)
+	}
+
+	doc = &parser.Document{Type: parser.LinkDoc, Name: "Other", Short: "Declared."}
+	l.Apply(doc, "dsdoc.yaml")
+	if doc.Name != "Other" || doc.Short != "Declared." || doc.Version != "1.2.0" {
+		t.Errorf("Link mismatch: %+v", doc)
+	}
+
+	// Without a link name the metadata has nowhere to go, and a root which
+	// becomes the link keeps its Short description.
+	var tests = []Link{{Version: "1.0.0"}, {Name: "Named"}}
+	for i, l := range tests {
+		p := parser.NewParser()
+		doc, _ := p.Build()
+		l.Apply(doc, "dsdoc.yaml")
+		if ds := parser.Validate(doc); len(ds) != 0 {
+			t.Errorf("%d. Unexpected validation problems:\n%v", i, ds)
+		}
+	}
+}
+
+// Ensure the link section of a dsdoc.yaml file sets the metadata of a @Link
+// found in the sources, rather than declaring a second link.
+func TestLink_Declared(t *testing.T) {
+	src := "link:\n  version: 1.0.0\n"
+	path, done := writeFile(t, "dsdoc.yaml", src)
+	defer done()
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := parser.NewParser()
+	p.Parse(trim.Batch{File: "link.dart", Line: 1, Lines: []string{`@Link Example`, ``, `An example.`}})
+	if err := p.Merge(decl.ReadConfig(path, []byte(src))); err != nil {
+		t.Fatalf("Unexpected merge error %q", err)
+	}
+	doc, err := p.Build()
+	if err != nil {
+		t.Fatalf("Unexpected build error %q", err)
+	}
+	c.Link.Apply(doc, c.File)
+	if ds := parser.Validate(doc); len(ds) != 0 {
+		t.Errorf("Unexpected validation problems:\n%v", ds)
+	}
+	if doc.Name != "Example" || doc.Version != "1.0.0" {
+		t.Errorf("Link mismatch: %+v", doc)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	path, done := writeFile(t, "dsdoc.yaml", yamlConfig)
+	defer done()
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := c.Print(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, s := range []string{"sort: alpha", "strict: warnings", "name: Example_Link", "format: md"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Output is missing %q:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "nodes") {
+		t.Errorf("Output contains declared DsDocs:\n%s", out)
+	}
+}
@@ -0,0 +1,401 @@
+// Package decl loads DsDocs declared in YAML or JSON files, as an alternative
+// to DsDoc comments.
+//
+// A declaration file holds an optional link and lists of nodes and actions:
+//
+//	link:
+//	  name: Example_Link
+//	  version: 1.2.0
+//	nodes:
+//	  - path: device
+//	    metaType: DeviceNode
+//	    parent: root
+//	    short: A device.
+//	    valueType: enum[on,off]
+//	    writable: write
+//	actions:
+//	  - path: Reset
+//	    parent: DeviceNode
+//	    short: Resets the device.
+//	    params:
+//	      - name: force
+//	        type: bool
+//	        description: Skip the safety checks.
+//
+// JSON files are read as YAML, which they are a subset of, so that every
+// problem is reported with its location.
+//
+// The project configuration file may also declare DsDocs. It is read by
+// ReadConfig, which leaves its link and other settings to package config,
+// which applies the link metadata once the tree is built.
+package decl
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/butlermatt/dsdoc/parser"
+	"github.com/butlermatt/dsdoc/types"
+)
+
+// Match reports if the file at path is a declaration file: dsdoc.yaml,
+// dsdoc.yml or dsdoc.json, or a file with the extension .dsdoc.yaml,
+// .dsdoc.yml or .dsdoc.json.
+func Match(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	for _, ext := range []string{".yaml", ".yml", ".json"} {
+		if base == "dsdoc"+ext || strings.HasSuffix(base, ".dsdoc"+ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Read reads the declaration file at path, whose content is data, into a
+// Fragment, which is added to the tree by Parser.Merge.
+func Read(path string, data []byte) *parser.Fragment {
+	l := &loader{f: &parser.Fragment{}, file: path}
+	l.load(data)
+	return l.f
+}
+
+// ReadConfig is like Read for the project configuration file, whose link and
+// settings are read by package config rather than reported.
+func ReadConfig(path string, data []byte) *parser.Fragment {
+	l := &loader{f: &parser.Fragment{}, file: path, config: true}
+	l.load(data)
+	return l.f
+}
+
+// loader reads the documents of a single file.
+type loader struct {
+	f    *parser.Fragment
+	file string
+	// config is true if the file is the project configuration.
+	config bool
+}
+
+var yamlErrLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+func (l *loader) load(data []byte) {
+	var n yaml.Node
+	if err := yaml.Unmarshal(data, &n); err != nil {
+		pos := parser.Position{File: l.file}
+		msg := err.Error()
+		if m := yamlErrLine.FindStringSubmatch(msg); m != nil {
+			pos.Line, _ = strconv.Atoi(m[1])
+			msg = m[2]
+		}
+		l.errorf(pos, parser.CodeSyntax, "%s", msg)
+		return
+	}
+	if len(n.Content) == 0 {
+		return
+	}
+
+	root := n.Content[0]
+	if !l.expect(root, yaml.MappingNode, "a mapping") {
+		return
+	}
+	l.fields(root, func(key string, v *yaml.Node) bool {
+		switch key {
+		case "link":
+			if l.config {
+				return true
+			}
+			if l.expect(v, yaml.MappingNode, "a mapping") {
+				l.doc(parser.LinkDoc, v)
+			}
+		case "nodes", "actions":
+			ty := parser.NodeDoc
+			if key == "actions" {
+				ty = parser.ActionDoc
+			}
+			if l.expect(v, yaml.SequenceNode, "a list") {
+				for _, dv := range v.Content {
+					if l.expect(dv, yaml.MappingNode, "a mapping") {
+						l.doc(ty, dv)
+					}
+				}
+			}
+		default:
+			return l.config
+		}
+		return true
+	})
+}
+
+// doc reads the document declared by the mapping n.
+func (l *loader) doc(ty parser.DocType, n *yaml.Node) {
+	d := &parser.Document{Type: ty, Pos: l.pos(n)}
+	var writablePos, returnPos, modePos, invokePos parser.Position
+	var writable, ret, mode, invokable string
+	l.fields(n, func(key string, v *yaml.Node) bool {
+		switch key {
+		case "path":
+			if s, ok := l.scalar(v); ok {
+				d.Path, d.MetaName = s, s
+				if d.Name == "" {
+					d.Name = s
+				}
+			}
+		case "name":
+			d.Name, _ = l.scalar(v)
+		case "metaType":
+			if s, ok := l.scalar(v); ok {
+				d.MetaName = s
+				if d.Name == "" {
+					d.Name = s
+				}
+			}
+		case "is":
+			d.Is, _ = l.scalar(v)
+		case "parent":
+			d.ParentName, _ = l.scalar(v)
+		case "short":
+			d.Short, _ = l.scalar(v)
+		case "long":
+			s, _ := l.scalar(v)
+			d.Long = parser.ParseBlocks(s)
+		case "params":
+			d.Params = l.params(v, true)
+		case "return":
+			ret, _ = l.scalar(v)
+			returnPos = l.pos(v)
+		case "returnMode":
+			mode, _ = l.scalar(v)
+			modePos = l.pos(v)
+		case "invokable":
+			invokable, _ = l.scalar(v)
+			invokePos = l.pos(v)
+		case "columns":
+			d.Columns = l.params(v, false)
+		case "attributes":
+			d.Attributes = l.params(v, false)
+		case "configs":
+			d.Configs = l.params(v, false)
+		case "examples":
+			d.Examples = l.examples(v)
+		case "valueType":
+			d.ValueType = l.typ(v)
+		case "writable":
+			writable, _ = l.scalar(v)
+			writablePos = l.pos(v)
+		case "version":
+			d.Version, _ = l.scalar(v)
+		case "author":
+			d.Author, _ = l.scalar(v)
+		case "license":
+			d.License, _ = l.scalar(v)
+		case "homepage":
+			d.Homepage, _ = l.scalar(v)
+		default:
+			return false
+		}
+		return true
+	})
+
+	var err error
+	if d.Writable, err = parser.ParseWriteType(writable); err != nil {
+		l.errorf(writablePos, parser.CodeSyntax, "%s", err)
+	}
+	if d.Return, err = parser.ParseResultType(ret); err != nil {
+		l.errorf(returnPos, parser.CodeSyntax, "%s", err)
+	}
+	if d.ReturnMode, err = parser.ParseTableMode(mode); err != nil {
+		l.errorf(modePos, parser.CodeSyntax, "%s", err)
+	}
+	if d.Invokable, err = parser.ParseInvokeType(invokable); err != nil {
+		l.errorf(invokePos, parser.CodeSyntax, "%s", err)
+	}
+	l.f.Docs = append(l.f.Docs, d)
+}
+
+// params reads the list of parameters or columns n. The default,
+// placeholder, required and editor keys are only accepted if meta is true.
+func (l *loader) params(n *yaml.Node, meta bool) []*parser.Parameter {
+	if !l.expect(n, yaml.SequenceNode, "a list") {
+		return nil
+	}
+	var ps []*parser.Parameter
+	for _, pn := range n.Content {
+		if !l.expect(pn, yaml.MappingNode, "a mapping") {
+			continue
+		}
+		p := &parser.Parameter{Pos: l.pos(pn)}
+		typed := false
+		l.fields(pn, func(key string, v *yaml.Node) bool {
+			switch key {
+			case "name":
+				p.Name, _ = l.scalar(v)
+			case "type":
+				p.Type, typed = l.typ(v), true
+			case "description":
+				p.Description, _ = l.scalar(v)
+			case "default":
+				if !meta {
+					return false
+				}
+				p.Default, _ = l.scalar(v)
+				p.DefaultPos = l.pos(v)
+			case "placeholder":
+				if !meta {
+					return false
+				}
+				p.Placeholder, _ = l.scalar(v)
+			case "required":
+				if !meta {
+					return false
+				}
+				if s, ok := l.scalar(v); ok {
+					if s != "true" && s != "false" {
+						l.errorf(l.pos(v), parser.CodeSyntax, "expected true or false, found %q", s)
+					}
+					p.Required = s == "true"
+				}
+			case "editor":
+				if !meta {
+					return false
+				}
+				p.Editor, _ = l.scalar(v)
+				p.EditorPos = l.pos(v)
+			default:
+				return false
+			}
+			return true
+		})
+		if p.Name == "" {
+			l.errorf(p.Pos, parser.CodeMissingName, "parameter missing required name")
+			continue
+		}
+		if !typed {
+			l.errorf(p.Pos, parser.CodeSyntax, "parameter missing required type")
+			continue
+		}
+		ps = append(ps, p)
+	}
+	return ps
+}
+
+// examples reads the list of examples n. The params of each are a mapping
+// of parameter names to values, and the result is given by a list of
+// columns and a list of rows of cells.
+func (l *loader) examples(n *yaml.Node) []*parser.ActionExample {
+	if !l.expect(n, yaml.SequenceNode, "a list") {
+		return nil
+	}
+	var exs []*parser.ActionExample
+	for _, en := range n.Content {
+		if !l.expect(en, yaml.MappingNode, "a mapping") {
+			continue
+		}
+		ex := &parser.ActionExample{Pos: l.pos(en)}
+		l.fields(en, func(key string, v *yaml.Node) bool {
+			switch key {
+			case "title":
+				ex.Title, _ = l.scalar(v)
+			case "params":
+				if !l.expect(v, yaml.MappingNode, "a mapping") {
+					break
+				}
+				for i := 0; i+1 < len(v.Content); i += 2 {
+					k := v.Content[i]
+					val, _ := l.scalar(v.Content[i+1])
+					ex.Params = append(ex.Params, &parser.ExampleParam{Name: k.Value, Value: val, Pos: l.pos(k)})
+				}
+			case "columns":
+				ex.Columns = l.strings(v)
+				ex.TablePos = l.pos(v)
+			case "rows":
+				if !l.expect(v, yaml.SequenceNode, "a list") {
+					break
+				}
+				for _, rn := range v.Content {
+					row := l.strings(rn)
+					if row != nil && len(row) != len(ex.Columns) {
+						l.errorf(l.pos(rn), parser.CodeExample, "example row has %d cells, expected %d", len(row), len(ex.Columns))
+						continue
+					}
+					ex.Rows = append(ex.Rows, row)
+				}
+			default:
+				return false
+			}
+			return true
+		})
+		exs = append(exs, ex)
+	}
+	return exs
+}
+
+// strings reads the list of strings n.
+func (l *loader) strings(n *yaml.Node) []string {
+	if !l.expect(n, yaml.SequenceNode, "a list") {
+		return nil
+	}
+	var r []string
+	for _, sn := range n.Content {
+		s, _ := l.scalar(sn)
+		r = append(r, s)
+	}
+	return r
+}
+
+// typ reads the DSA type n.
+func (l *loader) typ(n *yaml.Node) types.Type {
+	s, ok := l.scalar(n)
+	if !ok {
+		return types.Type{}
+	}
+	t, err := types.Parse(s)
+	if err != nil {
+		l.errorf(l.pos(n), parser.CodeInvalidType, "%s", err)
+	}
+	return t
+}
+
+// fields calls fn with each key and value of the mapping n. If fn returns
+// false the key is reported as unknown.
+func (l *loader) fields(n *yaml.Node, fn func(key string, v *yaml.Node) bool) {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		if !fn(k.Value, v) {
+			l.errorf(l.pos(k), parser.CodeUnknownAttr, "unknown field: %q", k.Value)
+		}
+	}
+}
+
+// scalar returns the value of the scalar n.
+func (l *loader) scalar(n *yaml.Node) (string, bool) {
+	if !l.expect(n, yaml.ScalarNode, "a string") {
+		return "", false
+	}
+	return strings.TrimSpace(n.Value), true
+}
+
+// expect reports if n is of kind, and records an error if it is not.
+func (l *loader) expect(n *yaml.Node, kind yaml.Kind, desc string) bool {
+	if n.Kind == kind {
+		return true
+	}
+	l.errorf(l.pos(n), parser.CodeSyntax, "expected %s", desc)
+	return false
+}
+
+func (l *loader) pos(n *yaml.Node) parser.Position {
+	return parser.Position{File: l.file, Line: n.Line, Col: n.Column}
+}
+
+func (l *loader) errorf(pos parser.Position, code parser.Code, format string, args ...interface{}) {
+	l.f.Diags = append(l.f.Diags, &parser.Diagnostic{
+		Severity: parser.ErrorSeverity,
+		Pos:      pos,
+		Code:     code,
+		Msg:      fmt.Sprintf(format, args...),
+	})
+}
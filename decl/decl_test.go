@@ -0,0 +1,226 @@
+package decl
+
+import (
+	"testing"
+
+	"github.com/butlermatt/dsdoc/parser"
+	"github.com/butlermatt/dsdoc/trim"
+	"github.com/butlermatt/dsdoc/types"
+)
+
+func TestMatch(t *testing.T) {
+	var tests = map[string]bool{
+		"dsdoc.yaml":             true,
+		"lib/dsdoc.yml":          true,
+		"dsdoc.json":             true,
+		"lib/devices.dsdoc.yaml": true,
+		"Devices.DsDoc.JSON":     true,
+		"pubspec.yaml":           false,
+		"nodes.dsdoc":            false,
+		"dsdoc.yaml.bak":         false,
+	}
+	for path, exp := range tests {
+		if got := Match(path); got != exp {
+			t.Errorf("%s. Match mismatch: exp=%v got=%v", path, exp, got)
+		}
+	}
+}
+
+const yamlSrc = `link:
+  name: Example_Link
+  version: 1.2.0
+  short: An example link.
+nodes:
+  - path: device
+    metaType: DeviceNode
+    parent: root
+    short: A device.
+    valueType: enum[on,off]
+    writable: write
+    attributes:
+      - {name: location, type: string}
+    configs:
+      - {name: secret, type: string, description: The secret.}
+actions:
+  - path: Reset
+    parent: DeviceNode
+    short: Resets the device.
+    return: table
+    returnMode: refresh
+    invokable: config
+    params:
+      - name: force
+        type: bool
+        description: Skip the safety checks.
+        default: false
+        required: true
+    columns:
+      - {name: ok, type: bool}
+    examples:
+      - title: Force a reset
+        params:
+          force: true
+        columns: [ok]
+        rows:
+          - [true]
+`
+
+func TestRead(t *testing.T) {
+	p := parser.NewParser()
+	if err := p.Merge(Read("devices.dsdoc.yaml", []byte(yamlSrc))); err != nil {
+		t.Fatalf("Unexpected error %q", err)
+	}
+	doc, err := p.Build()
+	if err != nil {
+		t.Fatalf("Unexpected build error %q", err)
+	}
+
+	if doc.Type != parser.LinkDoc || doc.Name != "Example_Link" || doc.Version != "1.2.0" {
+		t.Errorf("Link mismatch: %+v", doc)
+	}
+	if len(doc.Children) != 1 {
+		t.Fatalf("Expected 1 child, found %d", len(doc.Children))
+	}
+	dev := doc.Children[0]
+	if dev.Name != "device" || dev.MetaName != "DeviceNode" || dev.Writable != parser.Write {
+		t.Errorf("Node mismatch: %+v", dev)
+	}
+	if len(dev.Attributes) != 1 || dev.Attributes[0].Name != "location" || len(dev.Configs) != 1 || dev.Configs[0].Description != "The secret." {
+		t.Errorf("Attributes mismatch: %+v %+v", dev.Attributes, dev.Configs)
+	}
+	if exp := (types.Type{Kind: types.Enum, Options: []string{"on", "off"}}); !dev.ValueType.Equal(exp) {
+		t.Errorf("Value type mismatch: exp=%v got=%v", exp, dev.ValueType)
+	}
+	if exp := (parser.Position{File: "devices.dsdoc.yaml", Line: 6, Col: 5}); dev.Pos != exp {
+		t.Errorf("Position mismatch: exp=%v got=%v", exp, dev.Pos)
+	}
+	if len(dev.Children) != 1 {
+		t.Fatalf("Expected 1 action, found %d", len(dev.Children))
+	}
+	act := dev.Children[0]
+	if act.Type != parser.ActionDoc || act.Return != parser.TableResult || act.ReturnMode != parser.RefreshMode || act.Invokable != parser.InvokeConfig || len(act.Params) != 1 || len(act.Columns) != 1 {
+		t.Fatalf("Action mismatch: %+v", act)
+	}
+	if pr := act.Params[0]; pr.Name != "force" || pr.Type.Kind != types.Bool || pr.Description != "Skip the safety checks." || pr.Default != "false" || !pr.Required {
+		t.Errorf("Param mismatch: %+v", pr)
+	}
+	if exp := (parser.Position{File: "devices.dsdoc.yaml", Line: 27, Col: 18}); act.Params[0].DefaultPos != exp {
+		t.Errorf("Default position mismatch: exp=%v got=%v", exp, act.Params[0].DefaultPos)
+	}
+	if len(act.Examples) != 1 {
+		t.Fatalf("Expected 1 example, found %d", len(act.Examples))
+	}
+	ex := act.Examples[0]
+	if ex.Title != "Force a reset" || len(ex.Params) != 1 || ex.Params[0].Name != "force" || ex.Params[0].Value != "true" {
+		t.Errorf("Example mismatch: %+v", ex)
+	}
+	if len(ex.Columns) != 1 || len(ex.Rows) != 1 || ex.Rows[0][0] != "true" {
+		t.Errorf("Example result mismatch: %v %v", ex.Columns, ex.Rows)
+	}
+	if diags := parser.Validate(doc); len(diags) != 0 {
+		t.Errorf("Unexpected validation problems:\n%v", diags)
+	}
+}
+
+func TestRead_JSON(t *testing.T) {
+	src := "{\n\t\"nodes\": [\n\t\t{\"path\": \"device\", \"parent\": \"root\", \"short\": \"A device.\"}\n\t]\n}\n"
+	p := parser.NewParser()
+	if err := p.Merge(Read("dsdoc.json", []byte(src))); err != nil {
+		t.Fatalf("Unexpected error %q", err)
+	}
+	doc, err := p.Build()
+	if err != nil {
+		t.Fatalf("Unexpected build error %q", err)
+	}
+	if len(doc.Children) != 1 || doc.Children[0].Name != "device" {
+		t.Fatalf("Unexpected tree: %v", doc.Children)
+	}
+	if exp := (parser.Position{File: "dsdoc.json", Line: 3, Col: 3}); doc.Children[0].Pos != exp {
+		t.Errorf("Position mismatch: exp=%v got=%v", exp, doc.Children[0].Pos)
+	}
+}
+
+func TestRead_Errors(t *testing.T) {
+	var tests = []struct {
+		src  string
+		code parser.Code
+		pos  string
+	}{
+		{src: "nodes: [a", code: parser.CodeSyntax, pos: "e.yaml:1"},
+		{src: "nodes: {}", code: parser.CodeSyntax, pos: "e.yaml:1:8"},
+		{src: "node: []", code: parser.CodeUnknownAttr, pos: "e.yaml:1:1"},
+		{src: "nodes:\n  - path: a\n    parent: root\n    colour: red", code: parser.CodeUnknownAttr, pos: "e.yaml:4:5"},
+		{src: "nodes:\n  - path: a\n    parent: root\n    valueType: float", code: parser.CodeInvalidType, pos: "e.yaml:4:16"},
+		{src: "nodes:\n  - path: a\n    parent: root\n    writable: admin", code: parser.CodeSyntax, pos: "e.yaml:4:15"},
+		{src: "nodes:\n  - path: a\n    parent: root\n    writable: admin\n    valueType: string", code: parser.CodeSyntax, pos: "e.yaml:4:15"},
+		{src: "nodes:\n  - path: a", code: parser.CodeMissingParent, pos: "e.yaml:2:5"},
+		{src: "actions:\n  - path: a\n    parent: root\n    params:\n      - type: bool", code: parser.CodeMissingName, pos: "e.yaml:5:9"},
+		{src: "actions:\n  - path: a\n    parent: root\n    params:\n      - {name: force, description: x}", code: parser.CodeSyntax, pos: "e.yaml:5:9"},
+		{src: "nodes:\n  - path: a\n    parent: root\n    configs:\n      - name: secret", code: parser.CodeSyntax, pos: "e.yaml:5:9"},
+		{src: "actions:\n  - path: a\n    parent: root\n    examples:\n      - columns: [a]\n        rows: [[1, 2]]", code: parser.CodeExample, pos: "e.yaml:6:16"},
+		{src: "actions:\n  - path: a\n    parent: root\n    params:\n      - {name: b, type: bool, required: yes}", code: parser.CodeSyntax, pos: "e.yaml:5:41"},
+		{src: "actions:\n  - path: a\n    parent: root\n    columns:\n      - {name: b, type: int, default: 1}", code: parser.CodeUnknownAttr, pos: "e.yaml:5:30"},
+		{src: "actions:\n  - path: a\n    parent: root\n    return: rows", code: parser.CodeSyntax, pos: "e.yaml:4:13"},
+		{src: "actions:\n  - path: a\n    parent: root\n    invokable: admin", code: parser.CodeSyntax, pos: "e.yaml:4:16"},
+		{src: "link:\n  version: 1", code: parser.CodeMissingName, pos: "e.yaml:2:3"},
+	}
+
+	for i, tt := range tests {
+		p := parser.NewParser()
+		if err := p.Merge(Read("e.yaml", []byte(tt.src))); err == nil {
+			t.Errorf("%d. Expected an error", i)
+			continue
+		}
+		ds := p.Diagnostics()
+		if len(ds) != 1 {
+			t.Errorf("%d. Expected 1 diagnostic, found:\n%v", i, ds)
+			continue
+		}
+		if ds[0].Code != tt.code || ds[0].Pos.String() != tt.pos {
+			t.Errorf("%d. Diagnostic mismatch: exp=%s [%s] got=%s", i, tt.pos, tt.code, ds[0])
+		}
+	}
+}
+
+// Ensure the settings and link of the configuration file are left to
+// package config, but are reported in other files whatever their name.
+func TestReadConfig(t *testing.T) {
+	src := []byte("sort: alpha\nlink:\n  version: 1.0.0\nnodes:\n  - path: device\n    parent: root\n")
+	f := ReadConfig("dsdoc.yaml", src)
+	if len(f.Diags) != 0 || len(f.Docs) != 1 || f.Docs[0].Type != parser.NodeDoc {
+		t.Errorf("Unexpected fragment: %v %v", f.Docs, f.Diags)
+	}
+	for _, path := range []string{"lib/dsdoc.yaml", "dsdoc.json"} {
+		f := Read(path, src)
+		if len(f.Diags) != 1 || f.Diags[0].Code != parser.CodeUnknownAttr {
+			t.Errorf("%s. Expected an unknown field error, found:\n%v", path, f.Diags)
+		}
+		if len(f.Docs) != 2 || f.Docs[0].Type != parser.LinkDoc {
+			t.Errorf("%s. Expected a link and a node, found: %v", path, f.Docs)
+		}
+	}
+}
+
+// Ensure a MetaType declared both in a file and a comment is reported at
+// each location.
+func TestRead_Duplicate(t *testing.T) {
+	p := parser.NewParser()
+	src := []string{`//* @Node device`, `//* @Parent root`}
+	for _, b := range trim.TrimDsDoc(src, "lib/device.dart") {
+		if err := p.Parse(b); err != nil {
+			t.Fatalf("Unexpected error %q", err)
+		}
+	}
+	err := p.Merge(Read("dsdoc.yaml", []byte("nodes:\n  - path: device\n    parent: root\n")))
+	if err == nil {
+		t.Fatal("Expected a duplicate error")
+	}
+	ds := p.Diagnostics()
+	if len(ds) != 1 || ds[0].Code != parser.CodeDuplicate {
+		t.Fatalf("Expected a duplicate diagnostic, found:\n%v", ds)
+	}
+	exp := `dsdoc.yaml:2:5: error: DsDoc with meta name "device" already exists (previously defined at lib/device.dart:1:5) [duplicate-metatype]`
+	if ds[0].Error() != exp {
+		t.Errorf("Diagnostic mismatch:\nexp=%s\ngot=%s", exp, ds[0])
+	}
+}
@@ -0,0 +1,219 @@
+// Package diff finds the differences between the lines of two texts and
+// formats them as a unified diff.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Context is the number of unchanged lines shown around each change.
+const Context = 3
+
+// Op is the kind of an Edit.
+type Op int
+
+const (
+	// Equal lines are in both texts.
+	Equal Op = iota
+	// Delete lines are only in the first text.
+	Delete
+	// Insert lines are only in the second text.
+	Insert
+)
+
+// Edit is a line of a diff.
+type Edit struct {
+	Op   Op
+	Line string
+}
+
+// Lines returns the shortest list of edits which turns a into b.
+func Lines(a, b []string) []Edit {
+	// Most changes to generated documentation are small, so the common
+	// prefix and suffix are matched before the search.
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	var edits []Edit
+	for _, l := range a[:pre] {
+		edits = append(edits, Edit{Equal, l})
+	}
+	edits = append(edits, myers(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, l := range a[len(a)-suf:] {
+		edits = append(edits, Edit{Equal, l})
+	}
+	return edits
+}
+
+// myers returns the edits which turn a into b using the O(ND) algorithm
+// described by Eugene W. Myers in "An O(ND) Difference Algorithm and Its
+// Variations".
+func myers(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	max := n + m
+	off := max + 1
+	v := make([]int, 2*max+3)
+	// trace holds v for k in [-d, d] at the start of each round d.
+	var trace [][]int
+
+	d := 0
+search:
+	for ; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[off-d:off+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Follow the path back from the end, collecting the edits in reverse.
+	var rev []Edit
+	x, y := n, m
+	for ; d > 0; d-- {
+		tv := trace[d]
+		k := x - y
+		var pk int
+		if k == -d || (k != d && tv[k-1+d] < tv[k+1+d]) {
+			pk = k + 1
+		} else {
+			pk = k - 1
+		}
+		px := tv[pk+d]
+		py := px - pk
+		for x > px && y > py {
+			x--
+			y--
+			rev = append(rev, Edit{Equal, a[x]})
+		}
+		if x == px {
+			y--
+			rev = append(rev, Edit{Insert, b[y]})
+		} else {
+			x--
+			rev = append(rev, Edit{Delete, a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		rev = append(rev, Edit{Equal, a[x]})
+	}
+
+	edits := make([]Edit, len(rev))
+	for i, e := range rev {
+		edits[len(rev)-1-i] = e
+	}
+	return edits
+}
+
+// Unified returns the unified diff which turns a, named aName, into b, named
+// bName, or "" if they are the same.
+func Unified(aName, bName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	edits := Lines(split(a), split(b))
+
+	// aLine and bLine hold the number of lines of each text before each edit.
+	aLine := make([]int, len(edits)+1)
+	bLine := make([]int, len(edits)+1)
+	for i, e := range edits {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if e.Op != Insert {
+			aLine[i+1]++
+		}
+		if e.Op != Delete {
+			bLine[i+1]++
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", aName, bName)
+	for i := 0; i < len(edits); {
+		if edits[i].Op == Equal {
+			i++
+			continue
+		}
+		start := i - Context
+		if start < 0 {
+			start = 0
+		}
+
+		// Changes separated by no more than twice the context are joined
+		// into one hunk.
+		end := i
+		for j := i; j < len(edits); {
+			if edits[j].Op != Equal {
+				j++
+				end = j
+				continue
+			}
+			r := j
+			for r < len(edits) && edits[r].Op == Equal {
+				r++
+			}
+			if r == len(edits) || r-j > 2*Context {
+				break
+			}
+			j = r
+		}
+		end += Context
+		if end > len(edits) {
+			end = len(edits)
+		}
+
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", span(aLine[start], aLine[end]), span(bLine[start], bLine[end]))
+		for _, e := range edits[start:end] {
+			buf.WriteByte(" -+"[e.Op])
+			buf.WriteString(e.Line)
+			if !strings.HasSuffix(e.Line, "\n") {
+				buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return buf.String()
+}
+
+// span formats the lines from start to end of a hunk. An empty span is
+// given by the line before it.
+func span(start, end int) string {
+	switch n := end - start; n {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, n)
+	}
+}
+
+// split returns the lines of s, each with its line ending.
+func split(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
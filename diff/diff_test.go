@@ -0,0 +1,136 @@
+package diff
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	var tests = []struct {
+		a, b string
+		n    int // Expected number of inserts and deletes.
+	}{
+		{"", "", 0},
+		{"abc", "abc", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"abcabba", "cbabac", 5},
+		{"abcd", "acbd", 2},
+		{"xaxbx", "ayb", 4},
+	}
+	for i, tt := range tests {
+		a, b := strings.Split(tt.a, ""), strings.Split(tt.b, "")
+		edits := Lines(a, b)
+		if n := changes(edits); n != tt.n {
+			t.Errorf("%d. Edit count mismatch: exp=%d got=%d %v", i, tt.n, n, edits)
+		}
+		checkEdits(t, a, b, edits)
+	}
+}
+
+func TestLines_Random(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	gen := func() []string {
+		s := make([]string, r.Intn(40))
+		for i := range s {
+			s[i] = string(rune('a' + r.Intn(4)))
+		}
+		return s
+	}
+	for i := 0; i < 500; i++ {
+		a, b := gen(), gen()
+		checkEdits(t, a, b, Lines(a, b))
+	}
+}
+
+// checkEdits checks that edits turn a into b.
+func checkEdits(t *testing.T, a, b []string, edits []Edit) {
+	t.Helper()
+	var ga, gb []string
+	for _, e := range edits {
+		if e.Op != Insert {
+			ga = append(ga, e.Line)
+		}
+		if e.Op != Delete {
+			gb = append(gb, e.Line)
+		}
+	}
+	if strings.Join(ga, "") != strings.Join(a, "") || strings.Join(gb, "") != strings.Join(b, "") {
+		t.Errorf("Edits do not turn %q into %q: %v", a, b, edits)
+	}
+}
+
+func changes(edits []Edit) int {
+	n := 0
+	for _, e := range edits {
+		if e.Op != Equal {
+			n++
+		}
+	}
+	return n
+}
+
+func TestUnified(t *testing.T) {
+	lines := func(from, to int) string {
+		var s string
+		for i := from; i <= to; i++ {
+			s += string(rune('a'+i-1)) + "\n"
+		}
+		return s
+	}
+
+	var tests = []struct {
+		name string
+		a, b string
+		exp  string
+	}{
+		{"same", lines(1, 5), lines(1, 5), ""},
+		{
+			"change",
+			lines(1, 10),
+			lines(1, 4) + "E\n" + lines(6, 10),
+			"@@ -2,7 +2,7 @@\n b\n c\n d\n-e\n+E\n f\n g\n h\n",
+		},
+		{
+			"joined",
+			lines(1, 12),
+			"A\n" + lines(2, 7) + "H\n" + lines(9, 12),
+			"@@ -1,11 +1,11 @@\n-a\n+A\n b\n c\n d\n e\n f\n g\n-h\n+H\n i\n j\n k\n",
+		},
+		{
+			"separate",
+			lines(1, 12),
+			"A\n" + lines(2, 11) + "L\n",
+			"@@ -1,4 +1,4 @@\n-a\n+A\n b\n c\n d\n@@ -9,4 +9,4 @@\n i\n j\n k\n-l\n+L\n",
+		},
+		{
+			"added",
+			"",
+			"a\n",
+			"@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			"removed",
+			lines(1, 2),
+			"",
+			"@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			"no newline",
+			"a\nb",
+			"a\nb\n",
+			"@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+	}
+	for _, tt := range tests {
+		got := Unified("old", "new", tt.a, tt.b)
+		exp := tt.exp
+		if exp != "" {
+			exp = "--- old\n+++ new\n" + exp
+		}
+		if got != exp {
+			t.Errorf("%s. Diff mismatch:\nexp:\n%s\ngot:\n%s", tt.name, exp, got)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+// Package ignore matches paths against patterns with the semantics of
+// .gitignore files.
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Pattern is a single gitignore pattern.
+type Pattern struct {
+	// Negate is true for patterns starting with !, which re-include a path.
+	Negate bool
+	// DirOnly is true for patterns ending with /, which only match
+	// directories.
+	DirOnly bool
+	re      *regexp.Regexp
+}
+
+// Compile compiles the gitignore pattern p. Comments and blank patterns
+// return nil.
+func Compile(p string) (*Pattern, error) {
+	p = trimTrailingSpace(p)
+	if p == "" || p[0] == '#' {
+		return nil, nil
+	}
+
+	pat := &Pattern{}
+	if p[0] == '!' {
+		pat.Negate = true
+		p = p[1:]
+	} else if p[0] == '\\' && len(p) > 1 && (p[1] == '#' || p[1] == '!') {
+		p = p[1:]
+	}
+	if strings.HasSuffix(p, "/") {
+		pat.DirOnly = true
+		p = strings.TrimRight(p, "/")
+	}
+	if p == "" {
+		return nil, nil
+	}
+
+	// A pattern with a slash other than at the end is relative to the base,
+	// others match at any depth.
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch {
+		case strings.HasPrefix(p[i:], "**/") && (i == 0 || p[i-1] == '/'):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case p[i:] == "**" && i > 0 && p[i-1] == '/':
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			j := strings.IndexByte(p[i+1:], ']')
+			if j == -1 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := p[i+1 : i+1+j]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.Replace(class, `\`, `\\`, -1) + "]")
+			i += j + 1
+		case c == '\\' && i+1 < len(p):
+			i++
+			b.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	// A match of a directory also matches everything within it.
+	b.WriteString("(?:/.*)?$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", p, err)
+	}
+	pat.re = re
+	return pat, nil
+}
+
+// trimTrailingSpace removes trailing spaces from p unless they are escaped.
+func trimTrailingSpace(p string) string {
+	p = strings.TrimRight(p, "\r")
+	for strings.HasSuffix(p, " ") && !strings.HasSuffix(p, `\ `) {
+		p = p[:len(p)-1]
+	}
+	return p
+}
+
+// Match reports if the slash separated path, relative to the pattern's base,
+// matches the pattern.
+func (p *Pattern) Match(name string, isDir bool) bool {
+	if !p.re.MatchString(name) {
+		return false
+	}
+	if p.DirOnly && !isDir {
+		// A file only matches through one of its directories.
+		return p.re.MatchString(path.Dir(name)) && path.Dir(name) != "."
+	}
+	return true
+}
+
+// Matcher matches paths against a list of patterns, such as the lines of a
+// single ignore file.
+type Matcher struct {
+	// Base is the slash separated directory the patterns are relative to,
+	// "" for the root.
+	Base     string
+	patterns []*Pattern
+}
+
+// New compiles the patterns lines, which are relative to base.
+func New(base string, lines []string) (*Matcher, error) {
+	m := &Matcher{Base: strings.Trim(base, "/")}
+	for _, l := range lines {
+		p, err := Compile(l)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+	return m, nil
+}
+
+// ReadFile reads the ignore file at file, whose patterns are relative to base.
+func ReadFile(file, base string) (*Matcher, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	m, err := New(base, lines)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return m, nil
+}
+
+// Match returns whether the slash separated path, relative to the root,
+// matches any pattern, and if so whether it is ignored. The last matching
+// pattern decides.
+func (m *Matcher) Match(name string, isDir bool) (matched, ignored bool) {
+	if m.Base != "" {
+		if !strings.HasPrefix(name, m.Base+"/") {
+			return false, false
+		}
+		name = name[len(m.Base)+1:]
+	}
+	for i := len(m.patterns) - 1; i >= 0; i-- {
+		if p := m.patterns[i]; p.Match(name, isDir) {
+			return true, !p.Negate
+		}
+	}
+	return false, false
+}
+
+// Tree holds the matchers of the ignore files found while walking a
+// directory tree. Patterns in a subdirectory take precedence over those of
+// its parents, and later matchers over earlier ones in the same directory.
+type Tree struct {
+	// Names lists the ignore file names read from each directory, in order
+	// of increasing precedence, eg .gitignore then .dsdocignore.
+	Names []string
+	dirs  map[string][]*Matcher
+	extra []*Matcher
+}
+
+// NewTree returns a Tree which reads the ignore files names.
+func NewTree(names ...string) *Tree {
+	return &Tree{Names: names, dirs: make(map[string][]*Matcher)}
+}
+
+// Add adds m, which takes precedence over every ignore file.
+func (t *Tree) Add(m *Matcher) { t.extra = append(t.extra, m) }
+
+// Load reads the ignore files of the directory dir, which is the slash
+// separated path rel relative to the root. Missing files are skipped.
+func (t *Tree) Load(dir, rel string) error {
+	if rel == "." {
+		rel = ""
+	}
+	for _, n := range t.Names {
+		m, err := ReadFile(filepath.Join(dir, n), rel)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		t.dirs[rel] = append(t.dirs[rel], m)
+	}
+	return nil
+}
+
+// Ignored reports if the slash separated path, relative to the root, is
+// ignored.
+func (t *Tree) Ignored(name string, isDir bool) bool {
+	var ignored bool
+	check := func(ms []*Matcher) {
+		for _, m := range ms {
+			if ok, ig := m.Match(name, isDir); ok {
+				ignored = ig
+			}
+		}
+	}
+
+	check(t.dirs[""])
+	for i := 0; i < len(name); i++ {
+		if name[i] == '/' {
+			check(t.dirs[name[:i]])
+		}
+	}
+	check(t.extra)
+	return ignored
+}
@@ -0,0 +1,132 @@
+package ignore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPattern(t *testing.T) {
+	var tests = []struct {
+		pattern string
+		path    string
+		dir     bool
+		exp     bool
+	}{
+		{pattern: "*.g.dart", path: "lib/src/model.g.dart", exp: true},
+		{pattern: "*.g.dart", path: "model.dart", exp: false},
+		{pattern: "build", path: "build", dir: true, exp: true},
+		{pattern: "build", path: "pkg/build", dir: true, exp: true},
+		{pattern: "build", path: "build/lib/a.dart", exp: true},
+		{pattern: "build/", path: "build", dir: false, exp: false},
+		{pattern: "build/", path: "pkg/build", dir: true, exp: true},
+		{pattern: "build/", path: "build/a.dart", exp: true},
+		{pattern: "/vendor", path: "vendor", dir: true, exp: true},
+		{pattern: "/vendor", path: "pkg/vendor", dir: true, exp: false},
+		{pattern: "lib/gen", path: "lib/gen/a.go", exp: true},
+		{pattern: "lib/gen", path: "pkg/lib/gen", dir: true, exp: false},
+		{pattern: "**/node_modules", path: "a/b/node_modules", dir: true, exp: true},
+		{pattern: "**/node_modules", path: "node_modules", dir: true, exp: true},
+		{pattern: "lib/**/gen", path: "lib/gen", dir: true, exp: true},
+		{pattern: "lib/**/gen", path: "lib/a/b/gen", dir: true, exp: true},
+		{pattern: "lib/**", path: "lib/a/b.go", exp: true},
+		{pattern: "lib/**", path: "lib", dir: true, exp: false},
+		{pattern: "a?c.go", path: "abc.go", exp: true},
+		{pattern: "a?c.go", path: "a/c.go", exp: false},
+		{pattern: "[a-c].go", path: "b.go", exp: true},
+		{pattern: "[!a-c].go", path: "b.go", exp: false},
+		{pattern: `\#file`, path: "#file", exp: true},
+		{pattern: "name.go   ", path: "name.go", exp: true},
+	}
+
+	for _, tt := range tests {
+		p, err := Compile(tt.pattern)
+		if err != nil {
+			t.Fatalf("%q. Unexpected error %q", tt.pattern, err)
+		}
+		if got := p.Match(tt.path, tt.dir); got != tt.exp {
+			t.Errorf("%q. %q match mismatch: exp=%v got=%v", tt.pattern, tt.path, tt.exp, got)
+		}
+	}
+}
+
+func TestCompile_Skip(t *testing.T) {
+	for _, s := range []string{"", "   ", "# comment", "/"} {
+		if p, err := Compile(s); p != nil || err != nil {
+			t.Errorf("%q. Expected no pattern, got %v, %v", s, p, err)
+		}
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	m, err := New("pkg", []string{"*.go", "!keep.go", "# comment"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		path    string
+		matched bool
+		ignored bool
+	}{
+		{path: "pkg/a.go", matched: true, ignored: true},
+		{path: "pkg/sub/keep.go", matched: true, ignored: false},
+		{path: "a.go", matched: false, ignored: false},
+		{path: "pkg/a.dart", matched: false, ignored: false},
+	}
+	for _, tt := range tests {
+		matched, ignored := m.Match(tt.path, false)
+		if matched != tt.matched || ignored != tt.ignored {
+			t.Errorf("%s. Match mismatch: exp=%v,%v got=%v,%v", tt.path, tt.matched, tt.ignored, matched, ignored)
+		}
+	}
+}
+
+func TestTree(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		".gitignore":       "build/\n*.log\n",
+		".dsdocignore":     "!debug.log\ngen/\n",
+		"lib/.dsdocignore": "!gen/\n",
+	}
+	for name, data := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := ioutil.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tr := NewTree(".gitignore", ".dsdocignore")
+	for _, rel := range []string{".", "lib"} {
+		if err := tr.Load(filepath.Join(dir, rel), rel); err != nil {
+			t.Fatal(err)
+		}
+	}
+	extra, _ := New("", []string{"lib/skip.dart"})
+	tr.Add(extra)
+
+	var tests = []struct {
+		path string
+		dir  bool
+		exp  bool
+	}{
+		{path: "build", dir: true, exp: true},
+		{path: "out.log", exp: true},
+		{path: "debug.log", exp: false},
+		{path: "gen", dir: true, exp: true},
+		{path: "lib/gen", dir: true, exp: false},
+		{path: "lib/a.dart", exp: false},
+		{path: "lib/skip.dart", exp: true},
+	}
+	for _, tt := range tests {
+		if got := tr.Ignored(tt.path, tt.dir); got != tt.exp {
+			t.Errorf("%s. Ignored mismatch: exp=%v got=%v", tt.path, tt.exp, got)
+		}
+	}
+}
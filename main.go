@@ -4,123 +4,462 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
+	"runtime/debug"
 	"strings"
 
+	"github.com/butlermatt/dsdoc/cache"
+	"github.com/butlermatt/dsdoc/config"
+	"github.com/butlermatt/dsdoc/diff"
 	"github.com/butlermatt/dsdoc/parser"
+	"github.com/butlermatt/dsdoc/render"
+	"github.com/butlermatt/dsdoc/scan"
 	"github.com/butlermatt/dsdoc/trim"
 )
 
-const (
-	md = "md"   // Markdown
-	tx = "text" //text
-)
+// version identifies the release of the tool. The cache is discarded when
+// the version changes.
+const version = "0.2.0"
 
-var ValidFiles = [...]string{
-	".dart",
-	".java",
-	".go",
-	".c",
-	".cpp",
-	".cc",
-	".js",
-	".ts",
-	".es",
-}
+// verbs maps each subcommand which takes a verb to it, eg dsdoc config print.
+var verbs = map[string]string{"config": "print", "cache": "clean"}
 
-var psr *parser.Parser
+// isInput reports whether args, the arguments after dsdoc, start with a
+// directory to scan which is named like a subcommand, such as config, rather
+// than with the subcommand and its verb.
+func isInput(args []string) bool {
+	verb, ok := verbs[args[0]]
+	if !ok || len(args) > 1 && args[1] == verb {
+		return false
+	}
+	info, err := os.Stat(args[0])
+	return err == nil && info.IsDir()
+}
 
-func isSrcFile(extension string) bool {
-	for _, ext := range ValidFiles {
-		if extension == ext {
-			return true
+func main() {
+	if len(os.Args) > 1 && !isInput(os.Args[1:]) {
+		switch os.Args[1] {
+		case "cache":
+			cacheCmd(os.Args[2:])
+			return
+		case "watch":
+			watchCmd(os.Args[2:])
+			return
+		case "template":
+			templateCmd(os.Args[2:])
+			return
+		case "config":
+			configCmd(os.Args[2:])
+			return
 		}
 	}
 
-	return false
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "check" {
+		checkOnly = true
+		args = args[1:]
+	}
+	cfg, err := settings(flag.NewFlagSet("dsdoc", flag.ExitOnError), args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	var c *cache.Cache
+	if !noCache {
+		c = cache.Open(cachePath(cfg), toolVersion())
+	}
+	if err := generate(cfg, c); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
 
-func main() {
+// settings returns the effective configuration: the flags in args override
+// the configuration file, which overrides the defaults. The flags are defined
+// in fs, which may hold flags of its own.
+func settings(fs *flag.FlagSet, args []string) (*config.Config, error) {
 	var (
-		ty = flag.String("t", "md", "output type [md|text]")
-		fn = flag.String("o", "api.md", "output file name")
+		cf = fs.String("config", "", "configuration file (default "+strings.Join(config.Names, ", ")+" if present)")
+		ty = fs.String("t", "md", "comma separated output types ["+strings.Join(render.Names(), "|")+"]")
+		fn = fs.String("o", "", "output file name (default \"api\" with the extension of the output type)")
+		so = fs.String("sort", "actions-first", "child order [source|alpha|actions-first|nodes-first]")
+		tp = fs.String("template", "", "render with a text/template file, html/template if named *.html.tmpl")
+		gi = fs.Bool("gitignore", false, "skip the paths listed in .gitignore files as well as .dsdocignore files")
+		st = fs.String("strict", config.StrictErrors, "fail on [errors|warnings]")
+		jb = fs.Int("j", runtime.NumCPU(), "number of files to scan in parallel")
+
+		include, exclude globs
 	)
+	fs.Var(&include, "include", "only scan files matching the glob, may be repeated")
+	fs.Var(&exclude, "exclude", "skip files and directories matching the glob, may be repeated")
+	fs.BoolVar(&noCache, "no-cache", false, "neither read nor write the "+cache.File+" file")
+	fs.BoolVar(&checkOnly, "check", checkOnly, "print the differences between the outputs and their files, failing if there are any, without writing")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: dsdoc [flags] [path ...]")
+		fmt.Fprintln(os.Stderr, "       dsdoc check [flags] [path ...]")
+		fmt.Fprintln(os.Stderr, "       dsdoc watch [-interval d] [flags] [path ...]")
+		fmt.Fprintln(os.Stderr, "       dsdoc template <md|text>")
+		fmt.Fprintln(os.Stderr, "       dsdoc config print [flags] [path ...]")
+		fmt.Fprintln(os.Stderr, "       dsdoc cache clean [-config file]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
 
-	flag.Parse()
-	if *ty != md && *ty != tx {
-		fmt.Fprintf(os.Stderr, "Unknown output type: %q\n", *ty)
-		os.Exit(1)
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	cfg := config.Default()
+	path := *cf
+	if path == "" {
+		path = config.Find(".")
+	}
+	if path != "" {
+		var err error
+		if cfg, err = config.Load(path); err != nil {
+			return nil, err
+		}
 	}
 
-	wd, err := os.Getwd()
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	if set["t"] || set["o"] || set["template"] {
+		outs, err := flagOutputs(*ty, *fn, *tp, set["t"])
+		if err != nil {
+			return nil, err
+		}
+		cfg.Outputs = outs
+	}
+	if fs.NArg() > 0 {
+		cfg.Inputs = fs.Args()
+	}
+	if set["include"] {
+		cfg.Include = include
+	}
+	if set["exclude"] {
+		cfg.Exclude = exclude
+	}
+	if set["gitignore"] {
+		cfg.GitIgnore = *gi
+	}
+	if set["sort"] {
+		cfg.Sort = *so
 	}
+	if set["strict"] {
+		cfg.Strict = *st
+	}
+	if set["j"] || cfg.Jobs == 0 {
+		cfg.Jobs = *jb
+	}
+	return cfg, cfg.Validate()
+}
 
-	psr = parser.NewParser()
+// flagOutputs returns the outputs given by the -t, -o and -template flags.
+// When a template is given no other type is generated unless tySet.
+func flagOutputs(ty, fn, tp string, tySet bool) ([]config.Output, error) {
+	var outs []config.Output
+	if tp == "" || tySet {
+		for _, name := range strings.Split(ty, ",") {
+			outs = append(outs, config.Output{Format: strings.TrimSpace(name)})
+		}
+	}
+	if tp != "" {
+		outs = append(outs, config.Output{Template: tp})
+	}
+	if fn == "" {
+		return outs, nil
+	}
 
-	filepath.Walk(wd, walkFn)
+	for i := range outs {
+		f, err := outputFormat(outs[i])
+		if err != nil {
+			return nil, err
+		}
+		outs[i].Path = outputName(fn, f, len(outs) > 1)
+	}
+	return outs, nil
+}
 
-	doc, err := psr.Build()
+// outputFormat returns the format which renders o.
+func outputFormat(o config.Output) (*render.Format, error) {
+	if o.Template == "" {
+		return render.Lookup(o.Format)
+	}
+	t, err := render.LoadTemplate(o.Template)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		return nil, err
+	}
+	ext := render.TemplateExt(o.Template)
+	if ext == "" {
+		ext = ".txt"
 	}
+	return &render.Format{Name: filepath.Base(o.Template), Ext: ext, Renderer: t}, nil
+}
+
+// project holds the outputs and inputs of a configuration.
+type project struct {
+	cfg     *config.Config
+	formats []*render.Format
+	order   parser.SortOrder
+	walker  *walker
+}
 
-	var gb bytes.Buffer
-	if *ty == md {
-		gb = genMarkdown(doc)
+// newProject checks the settings of cfg and registers its syntaxes.
+func newProject(cfg *config.Config) (*project, error) {
+	p := &project{cfg: cfg}
+	for _, o := range cfg.Outputs {
+		f, err := outputFormat(o)
+		if err != nil {
+			return nil, err
+		}
+		p.formats = append(p.formats, f)
+	}
+	var err error
+	if p.order, err = parser.ParseSortOrder(cfg.Sort); err != nil {
+		return nil, err
+	}
+	syns, err := cfg.Syntaxes()
+	if err != nil {
+		return nil, err
+	}
+	for ext, syn := range syns {
+		trim.Syntaxes[ext] = syn
 	}
+	if p.walker, err = newWalker(cfg.Include, cfg.Exclude, cfg.GitIgnore); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
 
-	if *ty == tx {
-		gb = genText(doc)
+// files returns the files beneath the inputs which are scanned, in walk
+// order.
+func (p *project) files() ([]scan.File, error) {
+	var files []scan.File
+	for _, in := range p.cfg.Inputs {
+		err := p.walker.walk(in, func(path, name string) {
+			if scan.Match(path) {
+				files = append(files, scan.File{Path: path, Name: name})
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
+	return files, nil
+}
 
-	err = ioutil.WriteFile(*fn, gb.Bytes(), 0755)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
+// render builds the tree from the fragments of the files, prints the
+// diagnostics and, if there are no errors, returns each output.
+func (p *project) render(frags []*parser.Fragment) ([][]byte, error) {
+	// Fragments are merged in walk order, so the tree does not depend on
+	// the order files finish scanning.
+	psr := parser.NewParser()
+	for _, f := range frags {
+		psr.Merge(f)
+	}
+
+	doc, _ := psr.Build()
+	p.cfg.Link.Apply(doc, p.cfg.File)
+	diags := append(psr.Diagnostics(), parser.Validate(doc)...)
+	diags.Sort()
+	for _, d := range diags {
+		fmt.Fprintln(os.Stderr, d)
+	}
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("%d error(s) found, documentation not generated.", diags.Count(parser.ErrorSeverity))
+	}
+	if n := diags.Count(parser.WarningSeverity); n > 0 && p.cfg.Strict == config.StrictWarnings {
+		return nil, fmt.Errorf("%d warning(s) found, documentation not generated.", n)
 	}
 
+	opts := render.Options{Sort: p.order}
+	outs := make([][]byte, len(p.formats))
+	for i, f := range p.formats {
+		var b bytes.Buffer
+		if err := f.Render(&b, doc, opts); err != nil {
+			return nil, err
+		}
+		outs[i] = b.Bytes()
+	}
+	return outs, nil
 }
 
-func walkFn(path string, info os.FileInfo, err error) error {
-	if strings.HasPrefix(info.Name(), ".") {
-		if info.IsDir() {
-			return filepath.SkipDir
+// write renders the fragments and writes the outputs.
+func (p *project) write(frags []*parser.Fragment) error {
+	outs, err := p.render(frags)
+	if err != nil {
+		return err
+	}
+	for i, b := range outs {
+		if err := ioutil.WriteFile(p.outputPath(i), b, 0755); err != nil {
+			return err
 		}
-		return nil
 	}
+	return nil
+}
+
+// check renders the fragments and compares each output with the file it
+// would be written to, printing a unified diff of the differences to w.
+// Nothing is written, and an error is returned if any file is out of date.
+func (p *project) check(frags []*parser.Fragment, w io.Writer) error {
+	outs, err := p.render(frags)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		return err
+	}
+	stale := 0
+	for i, b := range outs {
+		name := p.outputPath(i)
+		old, err := ioutil.ReadFile(name)
+		oldName := name
+		if os.IsNotExist(err) {
+			oldName = os.DevNull
+		} else if err != nil {
+			return err
+		}
+		if d := diff.Unified(oldName, name, string(old), string(b)); d != "" {
+			fmt.Fprint(w, d)
+			fmt.Fprintf(os.Stderr, "%s is out of date\n", name)
+			stale++
+		}
+	}
+	if stale > 0 {
+		return fmt.Errorf("%d output(s) out of date, run dsdoc to regenerate them.", stale)
+	}
+	return nil
+}
+
+// outputPath returns the file output i is written to.
+func (p *project) outputPath(i int) string {
+	if name := p.cfg.Outputs[i].Path; name != "" {
+		return name
+	}
+	return outputName("", p.formats[i], false)
+}
+
+// generate scans the inputs of cfg and writes its outputs. If c is not nil
+// unchanged files are read from it, and it is saved once the scan completes.
+// If checkOnly is set the outputs are compared with the existing files
+// instead, and neither they nor the cache are written.
+func generate(cfg *config.Config, c *cache.Cache) error {
+	p, err := newProject(cfg)
+	if err != nil {
+		return err
+	}
+	files, err := p.files()
+	if err != nil {
+		return err
+	}
+
+	sc := &scan.Scanner{Jobs: cfg.Jobs, Cache: c, Config: cfg.File}
+	frags := sc.Scan(files)
+	if checkOnly {
+		return p.check(frags, os.Stdout)
 	}
-	if !info.Mode().IsRegular() {
-		return nil
+	if c != nil {
+		if err := c.Save(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
 	}
+	return p.write(frags)
+}
 
-	if !isSrcFile(filepath.Ext(path)) {
-		return nil
+// noCache and checkOnly are set by the -no-cache and -check flags. They are
+// not part of the configuration.
+var noCache, checkOnly bool
+
+// toolVersion returns the version the cache is keyed by, including the
+// revision the tool was built from when known.
+func toolVersion() string {
+	v := version
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				v += "+" + s.Value
+			case "vcs.modified":
+				if s.Value == "true" {
+					v += "-dirty"
+				}
+			}
+		}
 	}
+	return v
+}
 
-	data, err := ioutil.ReadFile(path)
+// cachePath returns the path of the cache file of the project configured by
+// cfg, which is kept beside the configuration file, or in the working
+// directory if there is none.
+func cachePath(cfg *config.Config) string {
+	if cfg.File == "" {
+		return cache.File
+	}
+	return filepath.Join(filepath.Dir(cfg.File), cache.File)
+}
+
+// cacheCmd manages the cache file.
+func cacheCmd(args []string) {
+	if len(args) == 0 || args[0] != "clean" {
+		fmt.Fprintln(os.Stderr, "usage: dsdoc cache clean [-config file]")
+		os.Exit(2)
+	}
+	cfg, err := settings(flag.NewFlagSet("dsdoc cache clean", flag.ExitOnError), args[1:])
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		return nil
+		os.Exit(2)
 	}
-	strs := strings.Split(string(data), "\n")
-	batches := trim.TrimDsDoc(strs)
-	if len(batches) <= 0 {
-		return nil
+	if err := cache.Clean(cachePath(cfg)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
+}
 
-	for _, bt := range batches {
-		err = psr.Parse(bt, info.Name())
+// templateCmd prints the default template of each named format, so that it
+// may be copied and customised.
+func templateCmd(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: dsdoc template <md|text>")
+		os.Exit(2)
+	}
+	for _, name := range args {
+		src, err := render.DefaultTemplate(name)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
+		fmt.Print(src)
 	}
+}
 
-	return nil
+// configCmd prints the effective configuration given the flags in args.
+func configCmd(args []string) {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "usage: dsdoc config print [flags] [path ...]")
+		os.Exit(2)
+	}
+	cfg, err := settings(flag.NewFlagSet("dsdoc config print", flag.ExitOnError), args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if cfg.File != "" {
+		fmt.Printf("# %s\n", cfg.File)
+	}
+	if err := cfg.Print(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// outputName returns the file name to write format f to. When several
+// formats are written, the extension of the named file is replaced by that
+// of each format.
+func outputName(name string, f *render.Format, multi bool) string {
+	if name == "" {
+		return "api" + f.Ext
+	}
+	if multi {
+		return strings.TrimSuffix(name, filepath.Ext(name)) + f.Ext
+	}
+	return name
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/butlermatt/dsdoc/config"
+)
+
+const deviceSrc = `//* @Node device
+//* @Parent root
+//*
+//* A device.
+`
+
+// testProject writes the source files to a new temporary directory, and
+// returns a configuration which scans it and writes api.md to it, and a
+// function to remove the directory.
+func testProject(t *testing.T, files map[string]string) (*config.Config, func()) {
+	dir, err := ioutil.TempDir("", "dsdoc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range files {
+		writeTestFile(t, filepath.Join(dir, name), src)
+	}
+	cfg := config.Default()
+	cfg.Inputs = []string{dir}
+	cfg.Outputs = []config.Output{{Format: "md", Path: filepath.Join(dir, "api.md")}}
+	cfg.Jobs = 1
+	return cfg, func() { os.RemoveAll(dir) }
+}
+
+func writeTestFile(t *testing.T, path, src string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerate_Check(t *testing.T) {
+	defer func(c bool) { checkOnly = c }(checkOnly)
+
+	cfg, done := testProject(t, map[string]string{"lib/device.dart": deviceSrc})
+	defer done()
+	out := cfg.Outputs[0].Path
+
+	// A missing output is out of date, and is not created.
+	checkOnly = true
+	if err := generate(cfg, nil); err == nil {
+		t.Error("Expected a missing output to fail the check")
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatalf("Expected the check not to write %s, stat returned %v", out, err)
+	}
+
+	// Once generated the output is up to date.
+	checkOnly = false
+	if err := generate(cfg, nil); err != nil {
+		t.Fatalf("Unexpected error %q", err)
+	}
+	checkOnly = true
+	if err := generate(cfg, nil); err != nil {
+		t.Errorf("Expected an up to date output to pass the check, got %q", err)
+	}
+
+	// A stale output fails the check and is left unchanged.
+	stale := "stale\n"
+	writeTestFile(t, out, stale)
+	if err := generate(cfg, nil); err == nil || !strings.Contains(err.Error(), "out of date") {
+		t.Errorf("Expected a stale output to fail the check, got %v", err)
+	}
+	if b, err := ioutil.ReadFile(out); err != nil || string(b) != stale {
+		t.Errorf("Expected the check to leave %s unchanged, found %q (%v)", out, b, err)
+	}
+}
+
+// Ensure a directory named like a subcommand is scanned unless the
+// subcommand's verb follows it.
+func TestIsInput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dsdoc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"config", "watch"} {
+		if err := os.Mkdir(name, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var tests = []struct {
+		args []string
+		exp  bool
+	}{
+		{args: []string{"config"}, exp: true},
+		{args: []string{"config", "lib"}, exp: true},
+		{args: []string{"config", "print"}, exp: false},
+		{args: []string{"cache"}, exp: false},
+		{args: []string{"cache", "clean"}, exp: false},
+		{args: []string{"watch"}, exp: false},
+		{args: []string{"lib"}, exp: false},
+	}
+	for _, tt := range tests {
+		if got := isInput(tt.args); got != tt.exp {
+			t.Errorf("%v. isInput mismatch: exp=%v got=%v", tt.args, tt.exp, got)
+		}
+	}
+}
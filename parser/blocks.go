@@ -0,0 +1,244 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BlockKind is the kind of a Block of a Long description.
+type BlockKind int
+
+const (
+	// ParagraphBlock is a run of text.
+	ParagraphBlock BlockKind = iota
+	// ListBlock is a bulleted or numbered list.
+	ListBlock
+	// CodeBlock is a code block, which keeps its line breaks and indentation.
+	CodeBlock
+)
+
+func (k BlockKind) String() string {
+	switch k {
+	case ListBlock:
+		return "List"
+	case CodeBlock:
+		return "Code"
+	}
+	return "Paragraph"
+}
+
+// Block is a paragraph, list or code block of a Long description.
+type Block struct {
+	Kind BlockKind
+	// Text is the text of a Paragraph, its lines joined by spaces, or the
+	// lines of a Code block.
+	Text string
+	// Lang is the language named after the opening fence of a Code block.
+	Lang string
+	// Items holds the text of each item of a List.
+	Items []string
+	// Start is the number of the first item of a numbered List, or 0 for a
+	// bulleted List.
+	Start int
+}
+
+// Blocks is a Long description. Printed, it gives the description in the
+// markdown form it is written in.
+type Blocks []Block
+
+// ParseBlocks parses a Long description written in a subset of markdown.
+// Blocks are separated by blank lines. A line starting with "- ", "* ", "+ "
+// or a number followed by ". " starts a list item, and the lines following it
+// continue the item. Code is either indented by four spaces or fenced by
+// lines of three backticks or tildes. Other lines are joined into paragraphs.
+func ParseBlocks(s string) Blocks {
+	lines := strings.Split(strings.Replace(s, "\r\n", "\n", -1), "\n")
+	dedent(lines)
+
+	var bs Blocks
+	// blank is set after a blank line, which ends a paragraph or list item.
+	blank := true
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		t := strings.TrimSpace(l)
+		var last *Block
+		if len(bs) > 0 {
+			last = &bs[len(bs)-1]
+		}
+
+		switch {
+		case t == "":
+			blank = true
+			continue
+
+		case isFence(t):
+			// The block is closed by a line of at least as many of the
+			// same fence characters.
+			fence := t[:len(t)-len(strings.TrimLeft(t, t[:1]))]
+			ind := lineIndent(l)
+			b := Block{Kind: CodeBlock, Lang: strings.TrimSpace(t[len(fence):])}
+			var code []string
+			for i++; i < len(lines); i++ {
+				if c := strings.TrimSpace(lines[i]); strings.HasPrefix(c, fence) && strings.Trim(c, fence[:1]) == "" {
+					break
+				}
+				code = append(code, trimIndent(lines[i], ind))
+			}
+			b.Text = strings.Join(code, "\n")
+			bs = append(bs, b)
+			blank = true
+			continue
+
+		case blank && lineIndent(l) >= 4:
+			var code []string
+			for ; i < len(lines); i++ {
+				if strings.TrimSpace(lines[i]) != "" && lineIndent(lines[i]) < 4 {
+					break
+				}
+				code = append(code, trimIndent(lines[i], 4))
+			}
+			i--
+			for len(code) > 0 && strings.TrimSpace(code[len(code)-1]) == "" {
+				code = code[:len(code)-1]
+			}
+			bs = append(bs, Block{Kind: CodeBlock, Text: strings.Join(code, "\n")})
+			blank = true
+			continue
+		}
+
+		if n, text, ok := listItem(t); ok && (blank || n <= 1 || (last != nil && last.Kind == ListBlock)) {
+			if last != nil && last.Kind == ListBlock && (n == 0) == (last.Start == 0) {
+				last.Items = append(last.Items, text)
+			} else {
+				bs = append(bs, Block{Kind: ListBlock, Items: []string{text}, Start: n})
+			}
+			blank = false
+			continue
+		}
+
+		switch {
+		case blank || last.Kind == CodeBlock:
+			bs = append(bs, Block{Kind: ParagraphBlock, Text: t})
+		case last.Kind == ListBlock:
+			last.Items[len(last.Items)-1] += " " + t
+		default:
+			last.Text += " " + t
+		}
+		blank = false
+	}
+	return bs
+}
+
+// String returns the description in markdown, in the form read by
+// ParseBlocks.
+func (bs Blocks) String() string {
+	var parts []string
+	for _, b := range bs {
+		switch b.Kind {
+		case ListBlock:
+			var items []string
+			for i, it := range b.Items {
+				if b.Start == 0 {
+					items = append(items, "- "+it)
+				} else {
+					items = append(items, fmt.Sprintf("%d. %s", b.Start+i, it))
+				}
+			}
+			parts = append(parts, strings.Join(items, "\n"))
+		case CodeBlock:
+			// The fence must not appear within the code, and only a tilde
+			// fence may have a backtick in its info string.
+			fence := "```"
+			if strings.Contains(b.Lang, "`") {
+				fence = "~~~"
+			}
+			for strings.Contains(b.Text, fence) {
+				fence += fence[:1]
+			}
+			parts = append(parts, fence+b.Lang+"\n"+b.Text+"\n"+fence)
+		default:
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// listItem returns the number and text of the list item on line t, which has
+// no surrounding whitespace. The number of a bullet point is 0.
+func listItem(t string) (int, string, bool) {
+	if len(t) > 2 && strings.ContainsRune("-*+", rune(t[0])) && t[1] == ' ' {
+		return 0, strings.TrimSpace(t[2:]), true
+	}
+	i := 0
+	for i < len(t) && i < 9 && t[i] >= '0' && t[i] <= '9' {
+		i++
+	}
+	if i == 0 || i+2 > len(t) || (t[i] != '.' && t[i] != ')') || t[i+1] != ' ' {
+		return 0, "", false
+	}
+	n, _ := strconv.Atoi(t[:i])
+	if n == 0 {
+		return 0, "", false
+	}
+	return n, strings.TrimSpace(t[i+2:]), true
+}
+
+// isFence reports whether t opens a fenced code block. As in CommonMark,
+// the info string of a backtick fence may not contain a backtick.
+func isFence(t string) bool {
+	if strings.HasPrefix(t, "```") {
+		return !strings.Contains(strings.TrimLeft(t, "`"), "`")
+	}
+	return strings.HasPrefix(t, "~~~")
+}
+
+// dedent removes the indentation common to the non-blank lines.
+func dedent(lines []string) {
+	min := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) != "" && (min == -1 || lineIndent(l) < min) {
+			min = lineIndent(l)
+		}
+	}
+	for i, l := range lines {
+		lines[i] = trimIndent(l, min)
+	}
+}
+
+// lineIndent returns the number of columns of leading whitespace of l, with
+// tab stops every four columns.
+func lineIndent(l string) int {
+	n := 0
+	for _, r := range l {
+		switch r {
+		case ' ':
+			n++
+		case '\t':
+			n += 4 - n%4
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// trimIndent removes up to n columns of leading whitespace from l. A tab
+// which spans column n is replaced by the spaces beyond it.
+func trimIndent(l string, n int) string {
+	col := 0
+	for i, r := range l {
+		if col >= n || (r != ' ' && r != '\t') {
+			if col > n {
+				return strings.Repeat(" ", col-n) + l[i:]
+			}
+			return l[i:]
+		}
+		if r == '\t' {
+			col += 4 - col%4
+		} else {
+			col++
+		}
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseBlocks(t *testing.T) {
+	var tests = []struct {
+		name string
+		in   []string
+		exp  Blocks
+	}{
+		{"empty", []string{``}, nil},
+		{
+			"paragraphs",
+			[]string{`First line`, `second line.`, ``, ``, `Second paragraph.`},
+			Blocks{{Text: "First line second line."}, {Text: "Second paragraph."}},
+		},
+		{
+			"bullets",
+			[]string{`Modes:`, `- tcp, the`, `  default`, `* tls`, ``, `+ udp`},
+			Blocks{
+				{Text: "Modes:"},
+				{Kind: ListBlock, Items: []string{"tcp, the default", "tls", "udp"}},
+			},
+		},
+		{
+			"numbered",
+			[]string{`3. three`, `4) four`, `- bullet`},
+			Blocks{
+				{Kind: ListBlock, Items: []string{"three", "four"}, Start: 3},
+				{Kind: ListBlock, Items: []string{"bullet"}},
+			},
+		},
+		{
+			"number in paragraph",
+			[]string{`Counts up to`, `2. Then stops.`},
+			Blocks{{Text: "Counts up to 2. Then stops."}},
+		},
+		{
+			"indented code",
+			[]string{`Example:`, ``, `    a := 1`, ``, `      b := 2`, ``, `After.`},
+			Blocks{
+				{Text: "Example:"},
+				{Kind: CodeBlock, Text: "a := 1\n\n  b := 2"},
+				{Text: "After."},
+			},
+		},
+		{
+			"indented lines in paragraph",
+			[]string{`Example:`, `    not code`},
+			Blocks{{Text: "Example: not code"}},
+		},
+		{
+			"fenced code",
+			[]string{"```go", `a := 1`, ``, `  b := 2`, "```", `After.`},
+			Blocks{
+				{Kind: CodeBlock, Lang: "go", Text: "a := 1\n\n  b := 2"},
+				{Text: "After."},
+			},
+		},
+		{
+			"long fence",
+			[]string{"~~~~", "```", "~~~", "~~~~~"},
+			Blocks{{Kind: CodeBlock, Text: "```\n~~~"}},
+		},
+		{
+			"backtick in backtick info",
+			[]string{"``` ``` x", `text`},
+			Blocks{{Text: "``` ``` x text"}},
+		},
+		{
+			"backtick in tilde info",
+			[]string{"~~~ a`b", "```", "~~~"},
+			Blocks{{Kind: CodeBlock, Lang: "a`b", Text: "```"}},
+		},
+		{
+			"unclosed fence",
+			[]string{"```", `code`},
+			Blocks{{Kind: CodeBlock, Text: "code"}},
+		},
+		{
+			"common indent",
+			[]string{`  Text`, ``, `      code`},
+			Blocks{{Text: "Text"}, {Kind: CodeBlock, Text: "code"}},
+		},
+	}
+	for _, tt := range tests {
+		got := ParseBlocks(strings.Join(tt.in, "\n"))
+		if !reflect.DeepEqual(got, tt.exp) {
+			t.Errorf("%s. Blocks mismatch:\nexp=%#v\ngot=%#v", tt.name, tt.exp, got)
+		}
+
+		// The printed form parses to the same blocks.
+		if again := ParseBlocks(got.String()); !reflect.DeepEqual(again, got) {
+			t.Errorf("%s. Blocks do not survive printing:\n%s\nparsed=%#v", tt.name, got, again)
+		}
+	}
+}
+
+func TestBlocks_String(t *testing.T) {
+	bs := Blocks{
+		{Text: "Intro."},
+		{Kind: ListBlock, Items: []string{"a", "b"}},
+		{Kind: ListBlock, Items: []string{"c", "d"}, Start: 2},
+		{Kind: CodeBlock, Lang: "md", Text: "```\nx\n```"},
+	}
+	exp := "Intro.\n\n- a\n- b\n\n2. c\n3. d\n\n````md\n```\nx\n```\n````"
+	if got := bs.String(); got != exp {
+		t.Errorf("String mismatch:\nexp=%q\ngot=%q", exp, got)
+	}
+}
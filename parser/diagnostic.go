@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity indicates how serious a Diagnostic is.
+type Severity int
+
+const (
+	// ErrorSeverity indicates a problem which prevents the documentation
+	// from being generated.
+	ErrorSeverity Severity = iota
+	// WarningSeverity indicates a likely mistake which does not prevent the
+	// documentation from being generated.
+	WarningSeverity
+)
+
+func (s Severity) String() string {
+	switch s {
+	case ErrorSeverity:
+		return "error"
+	case WarningSeverity:
+		return "warning"
+	}
+	return ""
+}
+
+// Code identifies the kind of problem reported by a Diagnostic.
+type Code string
+
+const (
+	// CodeSyntax indicates the DsDoc could not be parsed.
+	CodeSyntax Code = "syntax"
+	// CodeUnknownAttr indicates an unrecognised annotation.
+	CodeUnknownAttr Code = "unknown-attribute"
+	// CodeMissingName indicates a @Link DsDoc without a link name.
+	CodeMissingName Code = "missing-name"
+	// CodeMissingParent indicates a DsDoc without a Parent annotation.
+	CodeMissingParent Code = "missing-parent"
+	// CodeUnknownParent indicates a Parent which does not match any DsDoc.
+	CodeUnknownParent Code = "unknown-parent"
+	// CodeDuplicate indicates a MetaType which is declared more than once.
+	CodeDuplicate Code = "duplicate-metatype"
+	// CodeInvalidType indicates an unknown or malformed DSA type.
+	CodeInvalidType Code = "invalid-type"
+	// CodeLinkParent indicates a @Link DsDoc which declares a Parent.
+	CodeLinkParent Code = "link-parent"
+	// CodeInvalidAnnotation indicates an annotation which is not valid for
+	// the DocType it is declared on.
+	CodeInvalidAnnotation Code = "invalid-annotation"
+	// CodeMissingShort indicates a DsDoc without a Short description.
+	CodeMissingShort Code = "missing-short"
+	// CodeMissingMetaType indicates a DsDoc with neither a path name nor a
+	// MetaType.
+	CodeMissingMetaType Code = "missing-metatype"
+	// CodeNoColumns indicates an Action returning a table without columns.
+	CodeNoColumns Code = "table-without-columns"
+	// CodeActionChildren indicates an Action which is the Parent of other
+	// DsDocs.
+	CodeActionChildren Code = "action-children"
+	// CodeExample indicates a malformed @Example.
+	CodeExample Code = "invalid-example"
+	// CodeExampleName indicates an @Example parameter or column which is not
+	// declared by a @Param or @Column of the Action.
+	CodeExampleName Code = "unknown-example-name"
+	// CodeInvalidDefault indicates a @Default which is not a value of the
+	// type of its parameter.
+	CodeInvalidDefault Code = "invalid-default"
+	// CodeInvalidEditor indicates an unknown @Editor.
+	CodeInvalidEditor Code = "invalid-editor"
+	// CodeInvalidReturn indicates a table mode on an Action which does not
+	// return a table or stream.
+	CodeInvalidReturn Code = "invalid-return"
+)
+
+// Diagnostic is a problem found while parsing or building DsDocs.
+type Diagnostic struct {
+	Severity Severity
+	Pos      Position
+	Code     Code
+	Msg      string
+}
+
+func (d *Diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s: %s [%s]", d.Pos, d.Severity, d.Msg, d.Code)
+}
+
+// Diagnostics is a list of Diagnostic which may be used as an error.
+type Diagnostics []*Diagnostic
+
+func (ds Diagnostics) Error() string {
+	s := make([]string, len(ds))
+	for i, d := range ds {
+		s[i] = d.Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+// Count returns the number of diagnostics of the given severity.
+func (ds Diagnostics) Count(s Severity) int {
+	var n int
+	for _, d := range ds {
+		if d.Severity == s {
+			n++
+		}
+	}
+	return n
+}
+
+// HasErrors reports whether any of the diagnostics are of ErrorSeverity.
+func (ds Diagnostics) HasErrors() bool {
+	return ds.Count(ErrorSeverity) > 0
+}
+
+// Err returns the diagnostics as an error if any are of ErrorSeverity,
+// otherwise it returns nil.
+func (ds Diagnostics) Err() error {
+	if ds.HasErrors() {
+		return ds
+	}
+	return nil
+}
+
+// Sort orders the diagnostics by file, line and column.
+func (ds Diagnostics) Sort() {
+	sort.SliceStable(ds, func(i, j int) bool {
+		return ds[i].Pos.Before(ds[j].Pos)
+	})
+}
+
+func newDiag(sev Severity, pos Position, code Code, format string, args ...interface{}) *Diagnostic {
+	return &Diagnostic{Severity: sev, Pos: pos, Code: code, Msg: fmt.Sprintf(format, args...)}
+}
+
+func syntaxErr(pos Position, format string, args ...interface{}) error {
+	return newDiag(ErrorSeverity, pos, CodeSyntax, format, args...)
+}
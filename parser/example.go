@@ -0,0 +1,132 @@
+package parser
+
+import "strings"
+
+// ActionExample is a sample invocation of an Action, given by an @Example.
+type ActionExample struct {
+	// Title describes the example, and may be empty.
+	Title string
+	// Params holds the sample value of each parameter, in order.
+	Params []*ExampleParam
+	// Columns names the columns of the sample result, and Rows holds the
+	// cells of each row of the result.
+	Columns []string
+	Rows    [][]string
+	// TablePos is the position of the header of the result table.
+	TablePos Position
+	Pos      Position
+}
+
+// ExampleParam is the sample value of a parameter of an ActionExample.
+type ExampleParam struct {
+	Name  string
+	Value string
+	Pos   Position
+}
+
+// scanExample reads an @Example. The remainder of the annotation line is the
+// title. Each following line up to an @EndExample line or the next
+// annotation is either a "name: value" parameter, or a row of the result
+// table written as in markdown, "| cell | cell |", the first row naming the
+// columns. The parameters must come before the table.
+func (p *Parser) scanExample(d *Document) error {
+	ex := &ActionExample{Pos: p.pos()}
+	_, ex.Title = p.s.ScanRest()
+	d.Examples = append(d.Examples, ex)
+
+	if tok, _ := p.scan(); tok != EOL {
+		p.unscan()
+		return nil
+	}
+	_, lit := p.s.ScanLines()
+	start := p.s.startLine
+	p.s.acceptLine(string(AttrChar) + "EndExample")
+
+	names := make(map[string]bool)
+	for i, l := range strings.Split(lit, "\n") {
+		t := strings.TrimSpace(l)
+		pos := p.s.linePos(start + i)
+		report := func(format string, args ...interface{}) {
+			p.diags = append(p.diags, newDiag(ErrorSeverity, pos, CodeExample, format, args...))
+		}
+
+		switch {
+		case t == "":
+		case strings.HasPrefix(t, "|"):
+			cells := tableCells(t)
+			switch {
+			case isTableRule(cells):
+			case ex.Columns == nil:
+				ex.Columns, ex.TablePos = cells, pos
+			case len(cells) != len(ex.Columns):
+				report("example row has %d cells, expected %d", len(cells), len(ex.Columns))
+			default:
+				ex.Rows = append(ex.Rows, cells)
+			}
+		case ex.Columns != nil:
+			report("example parameters must come before the result table")
+		default:
+			i := strings.Index(t, ":")
+			if i == -1 || !isName(strings.TrimSpace(t[:i])) {
+				report("expected example parameter as name: value, found %q", t)
+				continue
+			}
+			name := strings.TrimSpace(t[:i])
+			if names[name] {
+				report("example parameter %q given more than once", name)
+				continue
+			}
+			names[name] = true
+			ex.Params = append(ex.Params, &ExampleParam{Name: name, Value: strings.TrimSpace(t[i+1:]), Pos: pos})
+		}
+	}
+	return nil
+}
+
+// tableCells returns the cells of the markdown table row t. A pipe within a
+// cell is escaped by a backslash.
+func tableCells(t string) []string {
+	t = strings.TrimPrefix(t, "|")
+	if strings.HasSuffix(t, "|") && !strings.HasSuffix(t, `\|`) {
+		t = t[:len(t)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(t); i++ {
+		switch {
+		case t[i] == '\\' && i+1 < len(t) && t[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case t[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(t[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// isTableRule reports whether cells are the rule below the header of a
+// markdown table, eg |---|:---:|.
+func isTableRule(cells []string) bool {
+	for _, c := range cells {
+		if strings.Trim(c, ":") == "" || strings.Trim(strings.Trim(c, ":"), "-") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// isName reports whether s is a valid parameter name.
+func isName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !isAlphaNum(r) && r != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/butlermatt/dsdoc/trim"
+)
+
+func TestParser_Example(t *testing.T) {
+	src := []string{
+		`//* @Action Add_Device`,
+		`//* @Parent root`,
+		`//*`,
+		`//* Adds a device.`,
+		`//*`,
+		`//* @Param url string The url.`,
+		`//* @Param name string The name.`,
+		`//* @Return values`,
+		`//* @Column success bool Success.`,
+		`//* @Column message string Message.`,
+		`//*`,
+		`//* @Example Add the office`,
+		`//* url: tcp://10.0.0.2:80`,
+		`//* name: Office`,
+		`//*`,
+		`//* | success | message     |`,
+		`//* |---------|:-----------:|`,
+		`//* | true    | Added a\|b  |`,
+		`//* @EndExample`,
+		`//*`,
+		`//* @Example`,
+		`//* name: Empty`,
+	}
+
+	p := NewParser()
+	for _, b := range trim.TrimDsDoc(src, "device.dart") {
+		if err := p.Parse(b); err != nil {
+			t.Fatalf("Unexpected error %q", err)
+		}
+	}
+	doc, _ := p.Build()
+	d := doc.Children[0]
+	if len(d.Examples) != 2 {
+		t.Fatalf("Expected 2 examples, found %d", len(d.Examples))
+	}
+
+	ex := d.Examples[0]
+	if ex.Title != "Add the office" || len(ex.Params) != 2 {
+		t.Fatalf("Example mismatch: %+v", ex)
+	}
+	if pr := ex.Params[0]; pr.Name != "url" || pr.Value != "tcp://10.0.0.2:80" || pr.Pos.Line != 13 {
+		t.Errorf("Param mismatch: %+v", pr)
+	}
+	if exp := []string{"success", "message"}; !reflect.DeepEqual(ex.Columns, exp) || ex.TablePos.Line != 16 {
+		t.Errorf("Columns mismatch: exp=%v got=%v at %v", exp, ex.Columns, ex.TablePos)
+	}
+	if exp := [][]string{{"true", "Added a|b"}}; !reflect.DeepEqual(ex.Rows, exp) {
+		t.Errorf("Rows mismatch: exp=%q got=%q", exp, ex.Rows)
+	}
+	if ex := d.Examples[1]; ex.Title != "" || len(ex.Params) != 1 || ex.Columns != nil {
+		t.Errorf("Example mismatch: %+v", ex)
+	}
+	if diags := Validate(doc); len(diags) != 0 {
+		t.Errorf("Unexpected validation problems:\n%v", diags)
+	}
+}
+
+func TestParser_ExampleErrors(t *testing.T) {
+	var tests = []struct {
+		lines []string
+		code  Code
+		pos   string
+	}{
+		{[]string{`a: 1`, `a: 2`}, CodeExample, "e.dart:8:1"},
+		{[]string{`not a param`}, CodeExample, "e.dart:7:1"},
+		{[]string{`|a|`, `a: 1`}, CodeExample, "e.dart:8:1"},
+		{[]string{`|a|`, `|1|2|`}, CodeExample, "e.dart:8:1"},
+		{[]string{`b: 1`}, CodeExampleName, "e.dart:7:1"},
+		{[]string{`a: 1`, `|a|b|`}, CodeExampleName, "e.dart:8:1"},
+	}
+
+	for i, tt := range tests {
+		lines := append([]string{`@Action Act`, `@Parent root`, ``, `Acts.`, ``, `@Example`}, tt.lines...)
+		lines = append(lines, `@EndExample`, `@Param a string A.`, `@Return values`, `@Column a string A.`)
+		p := NewParser()
+		p.Parse(trim.Batch{File: "e.dart", Line: 1, Lines: lines})
+		doc, _ := p.Build()
+		ds := append(p.Diagnostics(), Validate(doc)...)
+		if len(ds) != 1 {
+			t.Errorf("%d. Expected 1 diagnostic, found:\n%v", i, ds)
+			continue
+		}
+		if ds[0].Code != tt.code || ds[0].Pos.String() != tt.pos {
+			t.Errorf("%d. Diagnostic mismatch: exp=%s [%s] got=%s", i, tt.pos, tt.code, ds[0])
+		}
+	}
+
+	// @Example is only valid on Actions, and @EndExample must close one.
+	p := NewParser()
+	p.Parse(trim.Batch{File: "e.dart", Line: 1, Lines: []string{`@Node n`, `@Parent root`, ``, `A node.`, ``, `@Example`, `@EndExample`}})
+	doc, _ := p.Build()
+	if ds := Validate(doc); len(ds) != 1 || ds[0].Code != CodeInvalidAnnotation {
+		t.Errorf("Expected an invalid annotation, found:\n%v", ds)
+	}
+	p = NewParser()
+	if err := p.Parse(trim.Batch{File: "e.dart", Line: 1, Lines: []string{`@Action a`, `@Parent root`, ``, `An action.`, ``, `@EndExample`}}); err == nil {
+		t.Errorf("Expected an error for @EndExample without @Example")
+	}
+}
@@ -0,0 +1,281 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/butlermatt/dsdoc/types"
+)
+
+// JSONSchemaVersion is the version of the JSON document written by WriteJSON.
+// It is incremented whenever a change is made which existing readers cannot
+// safely ignore.
+const JSONSchemaVersion = 1
+
+// jsonFile is the top level object of the JSON schema.
+type jsonFile struct {
+	// Schema is the JSONSchemaVersion the file was written with.
+	Schema int `json:"schema"`
+	// Root is the root of the document tree. It is either the default root
+	// node, or the @Link document if one was declared.
+	Root *jsonDoc `json:"root"`
+}
+
+// jsonDoc is the JSON form of a Document. Fields which are unset are
+// omitted.
+type jsonDoc struct {
+	Type     string         `json:"type"` // Link, Node or Action
+	Name     string         `json:"name"`
+	Path     string         `json:"path,omitempty"`
+	MetaType string         `json:"metaType,omitempty"`
+	Is       string         `json:"is,omitempty"`
+	Parent   string         `json:"parent,omitempty"` // MetaType of the parent
+	Short    string         `json:"short,omitempty"`
+	Long     string         `json:"long,omitempty"`
+	Params   []*jsonParam   `json:"params,omitempty"`
+	Return   string         `json:"return,omitempty"`     // values, table or stream
+	Mode     string         `json:"returnMode,omitempty"` // append, refresh or stream
+	Invoke   string         `json:"invokable,omitempty"`  // read, write, config or never
+	Columns  []*jsonParam   `json:"columns,omitempty"`
+	Examples []*jsonExample `json:"examples,omitempty"`
+	Value    string         `json:"valueType,omitempty"` // in @Value form, eg enum[a,b]
+	Writable string         `json:"writable,omitempty"`  // never, write or config
+	Attrs    []*jsonParam   `json:"attributes,omitempty"`
+	Configs  []*jsonParam   `json:"configs,omitempty"`
+	Version  string         `json:"version,omitempty"`
+	Author   string         `json:"author,omitempty"`
+	License  string         `json:"license,omitempty"`
+	Homepage string         `json:"homepage,omitempty"`
+	Source   *Position      `json:"source,omitempty"`
+	Children []*jsonDoc     `json:"children,omitempty"`
+}
+
+// jsonParam is the JSON form of a Parameter.
+type jsonParam struct {
+	Name        string    `json:"name"`
+	Type        string    `json:"type"` // in @Param form, eg enum[a,b]
+	Description string    `json:"description,omitempty"`
+	Default     string    `json:"default,omitempty"`
+	Placeholder string    `json:"placeholder,omitempty"`
+	Required    bool      `json:"required,omitempty"`
+	Editor      string    `json:"editor,omitempty"`
+	Source      *Position `json:"source,omitempty"`
+}
+
+// jsonExample is the JSON form of an ActionExample.
+type jsonExample struct {
+	Title   string              `json:"title,omitempty"`
+	Params  []*jsonExampleParam `json:"params,omitempty"`
+	Columns []string            `json:"columns,omitempty"`
+	Rows    [][]string          `json:"rows,omitempty"`
+	Source  *Position           `json:"source,omitempty"`
+}
+
+// jsonExampleParam is the JSON form of an ExampleParam.
+type jsonExampleParam struct {
+	Name   string    `json:"name"`
+	Value  string    `json:"value"`
+	Source *Position `json:"source,omitempty"`
+}
+
+// WriteJSON writes the document tree below root to w as indented JSON. The
+// format is described by the JSON Output section of the README.
+func WriteJSON(w io.Writer, root *Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&jsonFile{Schema: JSONSchemaVersion, Root: toJSON(root)})
+}
+
+// ReadJSON reads a document tree written by WriteJSON, and returns its root.
+func ReadJSON(r io.Reader) (*Document, error) {
+	var f jsonFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, err
+	}
+	if f.Schema < 1 || f.Schema > JSONSchemaVersion {
+		return nil, fmt.Errorf("unsupported JSON schema version %d", f.Schema)
+	}
+	if f.Root == nil {
+		return nil, fmt.Errorf("JSON document has no root")
+	}
+	return fromJSON(f.Root, nil)
+}
+
+func toJSON(d *Document) *jsonDoc {
+	jd := &jsonDoc{
+		Type:     d.Type.String(),
+		Name:     d.Name,
+		Path:     d.Path,
+		MetaType: d.MetaName,
+		Is:       d.Is,
+		Short:    d.Short,
+		Long:     d.Long.String(),
+		Return:   d.Return.String(),
+		Mode:     d.ReturnMode.String(),
+		Invoke:   d.Invokable.String(),
+		Value:    d.ValueType.String(),
+		Version:  d.Version,
+		Author:   d.Author,
+		License:  d.License,
+		Homepage: d.Homepage,
+		Source:   jsonPos(d.Pos),
+	}
+	if d.Parent != nil {
+		jd.Parent = d.Parent.MetaName
+	}
+	if d.ValueType.Kind != types.None {
+		jd.Writable = d.Writable.String()
+	}
+	jd.Params = toJSONParams(d.Params)
+	jd.Columns = toJSONParams(d.Columns)
+	jd.Attrs = toJSONParams(d.Attributes)
+	jd.Configs = toJSONParams(d.Configs)
+	for _, ex := range d.Examples {
+		je := &jsonExample{Title: ex.Title, Columns: ex.Columns, Rows: ex.Rows, Source: jsonPos(ex.Pos)}
+		for _, p := range ex.Params {
+			je.Params = append(je.Params, &jsonExampleParam{Name: p.Name, Value: p.Value, Source: jsonPos(p.Pos)})
+		}
+		jd.Examples = append(jd.Examples, je)
+	}
+	for _, ch := range d.Children {
+		jd.Children = append(jd.Children, toJSON(ch))
+	}
+	return jd
+}
+
+func toJSONParams(ps []*Parameter) []*jsonParam {
+	var jps []*jsonParam
+	for _, p := range ps {
+		jps = append(jps, &jsonParam{
+			Name:        p.Name,
+			Type:        p.Type.String(),
+			Description: p.Description,
+			Default:     p.Default,
+			Placeholder: p.Placeholder,
+			Required:    p.Required,
+			Editor:      p.Editor,
+			Source:      jsonPos(p.Pos),
+		})
+	}
+	return jps
+}
+
+func jsonPos(p Position) *Position {
+	if !p.IsValid() {
+		return nil
+	}
+	return &p
+}
+
+func fromJSON(jd *jsonDoc, parent *Document) (*Document, error) {
+	d := &Document{
+		Path:     jd.Path,
+		Name:     jd.Name,
+		MetaName: jd.MetaType,
+		Is:       jd.Is,
+		Parent:   parent,
+		Short:    jd.Short,
+		Long:     ParseBlocks(jd.Long),
+		Version:  jd.Version,
+		Author:   jd.Author,
+		License:  jd.License,
+		Homepage: jd.Homepage,
+	}
+	if jd.Source != nil {
+		d.Pos = *jd.Source
+	}
+	if parent != nil {
+		d.ParentName = parent.MetaName
+	}
+
+	switch jd.Type {
+	case LinkDoc.String():
+		d.Type = LinkDoc
+	case NodeDoc.String():
+		d.Type = NodeDoc
+	case ActionDoc.String():
+		d.Type = ActionDoc
+	default:
+		return nil, fmt.Errorf("DsDoc %q has unknown type %q", jd.Name, jd.Type)
+	}
+
+	var err error
+	if jd.Value != "" {
+		if d.ValueType, err = types.Parse(jd.Value); err != nil {
+			return nil, fmt.Errorf("DsDoc %q: %v", jd.Name, err)
+		}
+	}
+	if d.Params, err = fromJSONParams(jd.Name, jd.Params); err != nil {
+		return nil, err
+	}
+	if d.Columns, err = fromJSONParams(jd.Name, jd.Columns); err != nil {
+		return nil, err
+	}
+	if d.Attributes, err = fromJSONParams(jd.Name, jd.Attrs); err != nil {
+		return nil, err
+	}
+	if d.Configs, err = fromJSONParams(jd.Name, jd.Configs); err != nil {
+		return nil, err
+	}
+	for _, je := range jd.Examples {
+		ex := &ActionExample{Title: je.Title, Columns: je.Columns, Rows: je.Rows}
+		if je.Source != nil {
+			ex.Pos = *je.Source
+		}
+		for _, jp := range je.Params {
+			p := &ExampleParam{Name: jp.Name, Value: jp.Value}
+			if jp.Source != nil {
+				p.Pos = *jp.Source
+			}
+			ex.Params = append(ex.Params, p)
+		}
+		d.Examples = append(d.Examples, ex)
+	}
+
+	if d.Writable, err = ParseWriteType(jd.Writable); err != nil {
+		return nil, fmt.Errorf("DsDoc %q: %v", jd.Name, err)
+	}
+	if d.Return, err = ParseResultType(jd.Return); err != nil {
+		return nil, fmt.Errorf("DsDoc %q: %v", jd.Name, err)
+	}
+	if d.ReturnMode, err = ParseTableMode(jd.Mode); err != nil {
+		return nil, fmt.Errorf("DsDoc %q: %v", jd.Name, err)
+	}
+	if d.Invokable, err = ParseInvokeType(jd.Invoke); err != nil {
+		return nil, fmt.Errorf("DsDoc %q: %v", jd.Name, err)
+	}
+
+	for _, jch := range jd.Children {
+		ch, err := fromJSON(jch, d)
+		if err != nil {
+			return nil, err
+		}
+		d.Children = append(d.Children, ch)
+	}
+	return d, nil
+}
+
+func fromJSONParams(name string, jps []*jsonParam) ([]*Parameter, error) {
+	var ps []*Parameter
+	for _, jp := range jps {
+		t, err := types.Parse(jp.Type)
+		if err != nil {
+			return nil, fmt.Errorf("DsDoc %q, %q: %v", name, jp.Name, err)
+		}
+		p := &Parameter{
+			Name:        jp.Name,
+			Type:        t,
+			Description: jp.Description,
+			Default:     jp.Default,
+			Placeholder: jp.Placeholder,
+			Required:    jp.Required,
+			Editor:      jp.Editor,
+		}
+		if jp.Source != nil {
+			p.Pos = *jp.Source
+		}
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/butlermatt/dsdoc/trim"
+	"github.com/butlermatt/dsdoc/types"
+)
+
+func TestJSON_RoundTrip(t *testing.T) {
+	var batches = [][]string{
+		{`@Link Example`, `@Version 1.0.0`, ``, `An example link.`},
+		{
+			`@Action Add_Device`,
+			`@Is addDevice`,
+			`@Parent root`,
+			``,
+			`Adds a device.`,
+			``,
+			`A long description.`,
+			``,
+			`- one`,
+			`- two`,
+			``,
+			"```",
+			`add(name)`,
+			"```",
+			``,
+			`@Param name string The name of the device.`,
+			`@Required`,
+			`@Placeholder Office`,
+			`@Param mode enum[fast,slow] The mode.`,
+			`@Default fast`,
+			`@Editor textarea`,
+			`@Return stream append`,
+			`@Invokable write`,
+			`@Column success bool True on success.`,
+			`@Example Add the office`,
+			`name: Office`,
+			`mode: fast`,
+			`| success |`,
+			`| true |`,
+		},
+		{`@Node`, `@MetaType Device`, `@Parent root`, ``, `A device.`},
+		{`@Node mode`, `@Parent Device`, ``, `The mode.`, ``, `@Value enum[fast,slow] config`, `@Attribute floor int The floor.`, `@Config secret string`},
+	}
+
+	p := NewParser()
+	for i, b := range batches {
+		if err := p.Parse(trim.Batch{File: "link.dart", Line: i*20 + 1, Lines: b}); err != nil {
+			t.Fatalf("%d. Unexpected error %q", i, err)
+		}
+	}
+	root, err := p.Build()
+	if err != nil {
+		t.Fatalf("Unexpected build error %q", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, root); err != nil {
+		t.Fatalf("Unexpected write error %q", err)
+	}
+	if !strings.Contains(buf.String(), `"schema": 1`) {
+		t.Errorf("Missing schema version in:\n%s", buf.String())
+	}
+
+	got, err := ReadJSON(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Unexpected read error %q", err)
+	}
+
+	var buf2 bytes.Buffer
+	if err := WriteJSON(&buf2, got); err != nil {
+		t.Fatalf("Unexpected write error %q", err)
+	}
+	if buf.String() != buf2.String() {
+		t.Errorf("Round trip mismatch:\n  exp=%s\n  got=%s", buf.String(), buf2.String())
+	}
+
+	if got.Type != LinkDoc || got.Version != "1.0.0" {
+		t.Errorf("Root mismatch: got type=%s version=%q", got.Type, got.Version)
+	}
+	dev := got.Children[1]
+	if dev.Parent != got || dev.ParentName != "root" {
+		t.Errorf("Parent of %q not restored", dev.Name)
+	}
+	mode := dev.Children[0]
+	if mode.Writable != Config || len(mode.ValueType.Options) != 2 {
+		t.Errorf("Value of %q not restored: %v (%s)", mode.Name, mode.ValueType, mode.Writable)
+	}
+	if len(mode.Attributes) != 1 || mode.Attributes[0].Type.Kind != types.Int || len(mode.Configs) != 1 {
+		t.Errorf("Attributes of %q not restored: %+v %+v", mode.Name, mode.Attributes, mode.Configs)
+	}
+	act := got.Children[0]
+	if act.Return != StreamResult || act.ReturnMode != AppendMode || act.Invokable != InvokeWrite || act.Params[1].Default != "fast" {
+		t.Errorf("Action %q not restored: %+v", act.Name, act)
+	}
+	if exp := (Position{File: "link.dart", Line: 61, Col: 1}); mode.Pos != exp {
+		t.Errorf("Position mismatch: exp=%v got=%v", exp, mode.Pos)
+	}
+}
+
+func TestReadJSON_Errors(t *testing.T) {
+	var tests = []struct {
+		in  string
+		err string
+	}{
+		{in: `{"schema": 99, "root": {"type": "Node", "name": "root"}}`, err: "unsupported JSON schema version 99"},
+		{in: `{"schema": 1}`, err: "JSON document has no root"},
+		{in: `{"schema": 1, "root": {"type": "Thing", "name": "root"}}`, err: `DsDoc "root" has unknown type "Thing"`},
+		{in: `{"schema": 1, "root": {"type": "Node", "name": "root", "valueType": "strng"}}`, err: `DsDoc "root": unknown type "strng"`},
+	}
+
+	for i, tt := range tests {
+		_, err := ReadJSON(strings.NewReader(tt.in))
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%d. Error mismatch:\n  exp=%q\n  got=%v", i, tt.err, err)
+		}
+	}
+}
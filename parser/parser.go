@@ -2,6 +2,9 @@ package parser
 
 import (
 	"fmt"
+
+	"github.com/butlermatt/dsdoc/trim"
+	"github.com/butlermatt/dsdoc/types"
 )
 
 // DocType represents the type of document being parsed.
@@ -50,6 +53,139 @@ func (w WriteType) String() string {
 	return "never"
 }
 
+// ParseWriteType returns the WriteType named s, as returned by String. An
+// empty string is Never.
+func ParseWriteType(s string) (WriteType, error) {
+	switch s {
+	case "", Never.String():
+		return Never, nil
+	case Write.String():
+		return Write, nil
+	case Config.String():
+		return Config, nil
+	}
+	return Never, fmt.Errorf("unknown writable permission %q, expected never, write or config", s)
+}
+
+// InvokeType represents the permission required to invoke an Action.
+type InvokeType int
+
+const (
+	// InvokeUnset indicates no @Invokable was given.
+	InvokeUnset InvokeType = iota
+	// InvokeRead indicates the Action may be invoked with read privileges.
+	InvokeRead
+	// InvokeWrite indicates the Action requires write privileges.
+	InvokeWrite
+	// InvokeConfig indicates the Action requires config privileges.
+	InvokeConfig
+	// InvokeNever indicates the Action may not be invoked.
+	InvokeNever
+)
+
+func (i InvokeType) String() string {
+	switch i {
+	case InvokeRead:
+		return "read"
+	case InvokeWrite:
+		return "write"
+	case InvokeConfig:
+		return "config"
+	case InvokeNever:
+		return "never"
+	}
+	return ""
+}
+
+// ParseInvokeType returns the InvokeType named s, as returned by String. An
+// empty string is InvokeUnset.
+func ParseInvokeType(s string) (InvokeType, error) {
+	for i := InvokeUnset; i <= InvokeNever; i++ {
+		if s == i.String() {
+			return i, nil
+		}
+	}
+	return InvokeUnset, fmt.Errorf("unknown invoke permission %q, expected read, write, config or never", s)
+}
+
+// ResultType represents the kind of result an Action returns.
+type ResultType int
+
+const (
+	// NoResult indicates no @Return was given.
+	NoResult ResultType = iota
+	// ValuesResult indicates a single row of values.
+	ValuesResult
+	// TableResult indicates a table of rows.
+	TableResult
+	// StreamResult indicates rows which are sent as they become available.
+	StreamResult
+)
+
+func (r ResultType) String() string {
+	switch r {
+	case ValuesResult:
+		return "values"
+	case TableResult:
+		return "table"
+	case StreamResult:
+		return "stream"
+	}
+	return ""
+}
+
+// ParseResultType returns the ResultType named s, as returned by String.
+// "value" is accepted for values, and an empty string is NoResult.
+func ParseResultType(s string) (ResultType, error) {
+	if s == "value" {
+		return ValuesResult, nil
+	}
+	for r := NoResult; r <= StreamResult; r++ {
+		if s == r.String() {
+			return r, nil
+		}
+	}
+	return NoResult, fmt.Errorf("unknown result type %q, expected values, table or stream", s)
+}
+
+// TableMode represents how the rows of a table or stream result update the
+// rows already received.
+type TableMode int
+
+const (
+	// NoMode indicates no mode was given.
+	NoMode TableMode = iota
+	// AppendMode indicates rows are added to those already received.
+	AppendMode
+	// RefreshMode indicates rows replace those already received.
+	RefreshMode
+	// StreamMode indicates rows are handled as they arrive and not kept.
+	StreamMode
+)
+
+func (m TableMode) String() string {
+	switch m {
+	case AppendMode:
+		return "append"
+	case RefreshMode:
+		return "refresh"
+	case StreamMode:
+		return "stream"
+	}
+	return ""
+}
+
+// ParseTableMode returns the TableMode named s, as returned by String. An
+// empty string is NoMode.
+func ParseTableMode(s string) (TableMode, error) {
+	for m := NoMode; m <= StreamMode; m++ {
+		if s == m.String() {
+			return m, nil
+		}
+	}
+	return NoMode, fmt.Errorf("unknown table mode %q, expected append, refresh or stream", s)
+}
+
 // Document is the primary container of the DsDoc.
 type Document struct {
 	Type       DocType
@@ -61,31 +197,57 @@ type Document struct {
 	Parent     *Document
 	Children   []*Document
 	Short      string
-	Long       string
+	Long       Blocks
 	Params     []*Parameter
-	Return     string
+	Return     ResultType
+	ReturnMode TableMode
+	Invokable  InvokeType
 	Columns    []*Parameter
-	ValueType  string
+	Examples   []*ActionExample
+	ValueType  types.Type
 	Writable   WriteType
-	fn         string
+	Attributes []*Parameter
+	Configs    []*Parameter
+	Version    string
+	Author     string
+	License    string
+	Homepage   string
+	Pos        Position
 }
 
 // Parameter is a component of a Action type. Used as either a action
-// parameter or return column.
+// parameter or return column. It is also used for the attributes and configs
+// of a Node.
 type Parameter struct {
 	Name        string
-	Type        string
+	Type        types.Type
 	Description string
+	// Default, Placeholder, Required and Editor are given by the annotations
+	// which may follow a @Param. They are not used by columns.
+	Default     string
+	Placeholder string
+	Required    bool
+	Editor      string
+	Pos         Position
+	// DefaultPos and EditorPos are the positions of the @Default and
+	// @Editor annotations, if any.
+	DefaultPos, EditorPos Position
 }
 
+// Editors lists the values accepted by @Editor.
+var Editors = []string{"textarea", "password", "daterange", "color", "fileinput"}
+
 // Parser represents a parser, which extends the functionality of Scanner
 type Parser struct {
-	s   *Scanner
-	c   map[string]*Document
-	r   *Document
-	buf struct {
+	s     *Scanner
+	c     map[string]*Document
+	docs  []*Document
+	r     *Document
+	diags Diagnostics
+	buf   struct {
 		tok ItemToken
 		lit string
+		pos Position
 		b   bool
 	}
 }
@@ -93,9 +255,10 @@ type Parser struct {
 // NewParser returns a new instance of Parser
 func NewParser() *Parser {
 	root := &Document{
-		Type:  NodeDoc,
-		Name:  "root",
-		Short: "Root node of the DsLink",
+		Type:     NodeDoc,
+		Name:     "root",
+		MetaName: "root",
+		Short:    "Root node of the DsLink",
 	}
 	return &Parser{
 		c: map[string]*Document{root.Name: root},
@@ -103,16 +266,63 @@ func NewParser() *Parser {
 	}
 }
 
-// Parse will take the input string slice and try to parse the information.
-func (p *Parser) Parse(in []string, filename string) error {
-	p.s = NewScanner(in)
-	p.buf.b = false
-	doc := &Document{fn: filename}
+// Parse will take the input batch and try to parse the information. Any
+// problems found are recorded in the parser's Diagnostics, and the errors
+// found in this batch are also returned. After an error the parser resumes at
+// the next annotation, so a single batch may report several problems.
+func (p *Parser) Parse(b trim.Batch) error {
+	n := len(p.diags)
+	p.Merge(ParseFragment(b))
+	return p.diags[n:].Err()
+}
+
+// Fragment holds the documents parsed from part of the source, such as a
+// single file, before they are added to the tree.
+type Fragment struct {
+	Docs  []*Document
+	Diags Diagnostics
+}
+
+// ParseFragment parses the batches, which are usually those of a single
+// file, into a Fragment. It does not depend on any Parser, so fragments may
+// be parsed concurrently and merged once complete.
+func ParseFragment(bs ...trim.Batch) *Fragment {
+	f := &Fragment{}
+	p := &Parser{}
+	for _, b := range bs {
+		p.s = NewBatchScanner(b)
+		p.buf.b = false
+		if doc := p.parseDoc(); doc != nil {
+			f.Docs = append(f.Docs, doc)
+		}
+	}
+	f.Diags = p.diags
+	return f
+}
+
+// Merge adds the diagnostics and documents of f to the tree, in order.
+// Merging the fragments of a set of files in the same order always builds
+// the same tree. A fragment may be merged into several parsers in turn, so
+// the fragments of unchanged files can be reused. The errors found by f or
+// while adding its documents are returned.
+func (p *Parser) Merge(f *Fragment) error {
+	n := len(p.diags)
+	p.diags = append(p.diags, f.Diags...)
+	for _, doc := range f.Docs {
+		p.add(doc)
+	}
+	return p.diags[n:].Err()
+}
+
+func (p *Parser) parseDoc() *Document {
+	doc := &Document{}
 
 	// First token should be an Attribute character.
 	if tok, lit := p.scan(); tok != Attr {
-		return fmt.Errorf("found %q, expected %q", lit, AttrChar)
+		p.addErr(syntaxErr(p.pos(), "found %q, expected %q", lit, AttrChar))
+		return nil
 	}
+	doc.Pos = p.pos()
 
 	// Expect DsDoc to start with either @Action, @Node or @Link
 	tok, lit := p.scan()
@@ -124,19 +334,24 @@ func (p *Parser) Parse(in []string, filename string) error {
 	case Link:
 		doc.Type = LinkDoc
 	default:
-		return fmt.Errorf("Expect DocType, found %q", lit)
+		p.addErr(syntaxErr(p.pos(), "expected DocType, found %q", lit))
+		return nil
 	}
 
-	if tok, lit = p.scanIgnoreWs(); tok == Ident {
+	if tok, lit = p.scanIdent(); tok == Ident {
 		doc.Path = lit
 		doc.Name = lit
 		doc.MetaName = lit
 	} else if tok == EOF {
-		return fmt.Errorf("DsDoc unexpectedly terminated early. File: %s", doc.fn)
+		p.addErr(syntaxErr(p.pos(), "DsDoc unexpectedly terminated early"))
+		return nil
 	} else if tok != EOL {
-		return fmt.Errorf("Expected ident string or EOL, found %q", lit)
+		p.addErr(syntaxErr(p.pos(), "expected ident string or EOL, found %q", lit))
+		p.recover()
 	}
 
+	// param is the @Param which the annotation being scanned may add to.
+	var param *Parameter
 	for {
 		p.maybeEol() // Skip any possible end of lines.
 		tok, lit = p.scan()
@@ -146,15 +361,18 @@ func (p *Parser) Parse(in []string, filename string) error {
 			break
 		} else if tok == EOL {
 			r := p.s.peak()
-			if r != AttrChar {
-				tok, lit = p.scanText()
-				if tok == Text {
-					if doc.Short == "" {
-						doc.Short = lit
-					} else {
-						doc.Long = lit
-					}
+			if r == AttrChar {
+				continue
+			}
+			param = nil
+			// The first paragraph is the Short description, and the text
+			// following it up to the next attribute is the Long description.
+			if doc.Short == "" {
+				if tok, lit = p.scanText(); tok == Text {
+					doc.Short = lit
 				}
+			} else if tok, lit = p.s.ScanLines(); tok == Text {
+				doc.Long = append(doc.Long, ParseBlocks(lit)...)
 			}
 		} else if tok == Attr {
 			tok, lit = p.scan()
@@ -168,61 +386,153 @@ func (p *Parser) Parse(in []string, filename string) error {
 				err = p.scanParent(doc)
 			case Param:
 				err = p.scanParam(doc)
+			case Default, Placeholder, Required, Editor:
+				err = p.scanParamMeta(tok, param)
 			case Return:
 				err = p.scanReturn(doc)
+			case Invokable:
+				err = p.scanInvokable(doc)
 			case Column:
 				err = p.scanColumn(doc)
+			case Attribute:
+				err = p.scanParameter(&doc.Attributes)
+			case ConfigAttr:
+				err = p.scanParameter(&doc.Configs)
 			case Value:
 				err = p.scanValue(doc)
+			case Version:
+				err = p.scanRestText(&doc.Version)
+			case Author:
+				err = p.scanRestText(&doc.Author)
+			case License:
+				err = p.scanRestText(&doc.License)
+			case Homepage:
+				err = p.scanRestText(&doc.Homepage)
+			case Example:
+				err = p.scanExample(doc)
+			case EndExample:
+				err = syntaxErr(p.pos(), "@EndExample without a matching @Example")
 			default:
-				err = fmt.Errorf("Unknown attribute: %q. File: %s", lit, doc.fn)
+				err = newDiag(ErrorSeverity, p.pos(), CodeUnknownAttr, "unknown attribute: %q", lit)
 			}
 
 			if err != nil {
-				return err
+				p.addErr(err)
+				p.recover()
+			}
+			if tok == Param && err == nil {
+				param = doc.Params[len(doc.Params)-1]
+			} else if !tok.isParamMeta() {
+				param = nil
 			}
 		}
 	}
 
-	// TODO: Verify required values are set
-	if doc.Name == "" {
-		return fmt.Errorf("DsDoc missing required Name or MetaType field. File: %s", doc.fn)
-	}
-	ed := p.c[doc.MetaName]
-	if ed != nil {
-		return fmt.Errorf("DsDoc with meta name %q already exists. File: %s", doc.MetaName, doc.fn)
+	return doc
+}
+
+// add links doc into the tree, replacing any links made by another parser.
+// The Children of doc are ignored, the tree is linked by each document's
+// ParentName.
+func (p *Parser) add(doc *Document) {
+	doc.Parent, doc.Children = nil, nil
+
+	// Other required values are checked by Validate once the tree is built.
+	if doc.Type == LinkDoc {
+		if doc.Name == "" {
+			p.diags = append(p.diags, newDiag(ErrorSeverity, doc.Pos, CodeMissingName, "@Link DsDoc missing required link name"))
+			return
+		}
+		p.setLink(doc)
+		return
 	}
 
 	if doc.ParentName == "" {
-		return fmt.Errorf("DsDoc missing required Parent field. File: %s", doc.fn)
+		p.diags = append(p.diags, newDiag(ErrorSeverity, doc.Pos, CodeMissingParent, "DsDoc missing required Parent field"))
+		return
 	}
 
+	// A DsDoc without a name cannot be referenced as a Parent, but is still
+	// added to the tree so the missing MetaType can be reported.
+	if doc.MetaName != "" {
+		if ed := p.c[doc.MetaName]; ed != nil {
+			p.diags = append(p.diags, newDiag(ErrorSeverity, doc.Pos, CodeDuplicate, "DsDoc with meta name %q already exists (previously defined at %s)", doc.MetaName, ed.Pos))
+			return
+		}
+		p.c[doc.MetaName] = doc
+	}
+	p.docs = append(p.docs, doc)
+
 	pd := p.c[doc.ParentName]
 	if pd != nil {
 		doc.Parent = pd
 		pd.Children = append(pd.Children, doc)
 	}
-
-	p.c[doc.MetaName] = doc
-	return nil
 }
 
-// Build completes the final linking of documents and returns the root document.
+// Build completes the final linking of documents and returns the root
+// document. Children are ordered by their position in the source files.
+// Every Parent which cannot be located is reported, and the returned error
+// holds all errors found while parsing and building.
 func (p *Parser) Build() (*Document, error) {
-	for key, doc := range p.c {
-		if key == "root" {
-			continue
-		}
+	for _, doc := range p.docs {
 		if doc.Parent == nil {
 			pd, ok := p.c[doc.ParentName]
 			if !ok {
-				return nil, fmt.Errorf("Unable to locate Parent named %q referenced by %q. File: %s", doc.ParentName, key, doc.fn)
+				p.diags = append(p.diags, newDiag(ErrorSeverity, doc.Pos, CodeUnknownParent, "unable to locate Parent named %q referenced by %q", doc.ParentName, doc.Name))
+				continue
 			}
 			doc.Parent = pd
 			pd.Children = append(pd.Children, doc)
 		}
 	}
-	return p.r, nil
+	p.r.sortChildren()
+	return p.r, p.diags.Err()
+}
+
+// setLink replaces the root document with the @Link document d. Any
+// documents already attached to the root are moved to d.
+func (p *Parser) setLink(d *Document) {
+	if p.r.Type == LinkDoc {
+		p.diags = append(p.diags, newDiag(ErrorSeverity, d.Pos, CodeDuplicate, "@Link DsDoc already exists (previously defined at %s)", p.r.Pos))
+		return
+	}
+	if d.ParentName != "" {
+		p.diags = append(p.diags, newDiag(ErrorSeverity, d.Pos, CodeLinkParent, "@Link DsDoc cannot have a Parent"))
+	}
+
+	d.MetaName = p.r.MetaName
+	for _, ch := range p.r.Children {
+		ch.Parent = d
+	}
+	d.Children = append(d.Children, p.r.Children...)
+	p.c[d.MetaName] = d
+	p.r = d
+}
+
+// Diagnostics returns every problem found so far, in the order found.
+func (p *Parser) Diagnostics() Diagnostics { return p.diags }
+
+// addErr records err, which is expected to be a *Diagnostic.
+func (p *Parser) addErr(err error) {
+	d, ok := err.(*Diagnostic)
+	if !ok {
+		d = newDiag(ErrorSeverity, p.pos(), CodeSyntax, "%s", err)
+	}
+	p.diags = append(p.diags, d)
+}
+
+// recover skips the remainder of a bad annotation, up to the start of the
+// next annotation or the end of the batch.
+func (p *Parser) recover() {
+	p.buf.b = false
+	tok := p.buf.tok
+	for tok != EOF && !(tok == EOL && p.s.peak() == AttrChar) {
+		tok, _ = p.scan()
+	}
+	if tok == EOF {
+		p.unscan()
+	}
 }
 
 func (p *Parser) scan() (ItemToken, string) {
@@ -232,13 +542,16 @@ func (p *Parser) scan() (ItemToken, string) {
 	}
 
 	tok, lit := p.s.Scan()
-	p.buf.tok, p.buf.lit = tok, lit
+	p.buf.tok, p.buf.lit, p.buf.pos = tok, lit, p.s.Pos()
 
 	return tok, lit
 }
 
 func (p *Parser) unscan() { p.buf.b = true }
 
+// pos returns the position of the last scanned token.
+func (p *Parser) pos() Position { return p.buf.pos }
+
 func (p *Parser) scanIgnoreWs() (ItemToken, string) {
 	tok, lit := p.scan()
 	if tok == WS {
@@ -247,6 +560,16 @@ func (p *Parser) scanIgnoreWs() (ItemToken, string) {
 	return tok, lit
 }
 
+// scanIdent scans the next token ignoring whitespace. Keywords are returned
+// as Ident so they may also be used as names.
+func (p *Parser) scanIdent() (ItemToken, string) {
+	tok, lit := p.scanIgnoreWs()
+	if tok.isKeyword() {
+		tok = Ident
+	}
+	return tok, lit
+}
+
 func (p *Parser) scanTypeIgnoreWs() (ItemToken, string) {
 	if p.buf.b && p.buf.tok == TypeIdent {
 		p.buf.b = false
@@ -254,7 +577,7 @@ func (p *Parser) scanTypeIgnoreWs() (ItemToken, string) {
 	}
 
 	tok, lit := p.s.scanTypeIdent()
-	p.buf.tok, p.buf.lit = tok, lit
+	p.buf.tok, p.buf.lit, p.buf.pos = tok, lit, p.s.Pos()
 
 	return tok, lit
 }
@@ -266,14 +589,14 @@ func (p *Parser) scanText() (ItemToken, string) {
 	}
 
 	tok, lit := p.s.ScanText()
-	p.buf.tok, p.buf.lit = tok, lit
+	p.buf.tok, p.buf.lit, p.buf.pos = tok, lit, p.s.Pos()
 	return tok, lit
 }
 
 func (p *Parser) scanIs(d *Document) error {
-	tok, lit := p.scanIgnoreWs()
+	tok, lit := p.scanIdent()
 	if tok != Ident {
-		return fmt.Errorf("Expected Ident, found %q (%q). File: %s", lit, tok, d.fn)
+		return syntaxErr(p.pos(), "expected Ident, found %q", lit)
 	}
 	d.Is = lit
 
@@ -281,9 +604,9 @@ func (p *Parser) scanIs(d *Document) error {
 }
 
 func (p *Parser) scanMetaType(d *Document) error {
-	tok, lit := p.scanIgnoreWs()
+	tok, lit := p.scanIdent()
 	if tok != Ident {
-		return fmt.Errorf("Expected Ident, found %q (%q). File: %s", lit, tok, d.fn)
+		return syntaxErr(p.pos(), "expected Ident, found %q", lit)
 	}
 	if d.Name == "" {
 		d.Name = lit
@@ -293,67 +616,116 @@ func (p *Parser) scanMetaType(d *Document) error {
 }
 
 func (p *Parser) scanParent(d *Document) error {
-	tok, lit := p.scanIgnoreWs()
+	tok, lit := p.scanIdent()
 	if tok != Ident {
-		return fmt.Errorf("Expected Ident, found %q (%q). File: %s", lit, tok, d.fn)
+		return syntaxErr(p.pos(), "expected Ident, found %q", lit)
 	}
 	d.ParentName = lit
 	return nil
 }
 
 func (p *Parser) scanParam(d *Document) error {
-	param := &Parameter{}
-	tok, lit := p.scanIgnoreWs()
-	if tok != Ident {
-		return fmt.Errorf("Expected Ident, found %q (%q). File: %s", lit, tok, d.fn)
+	return p.scanParameter(&d.Params)
+}
+
+// scanParamMeta reads the annotation tok, one of @Default, @Placeholder,
+// @Required or @Editor, which adds to the preceding @Param, param.
+func (p *Parser) scanParamMeta(tok ItemToken, param *Parameter) error {
+	pos := p.pos()
+	if param == nil {
+		return syntaxErr(pos, "@%s must follow a @Param", p.buf.lit)
 	}
-	param.Name = lit
 
-	tok, lit = p.scanTypeIgnoreWs()
-	if tok != TypeIdent {
-		return fmt.Errorf("Expected Ident, found %q (%q). File: %s", lit, tok, d.fn)
+	switch tok {
+	case Default:
+		_, lit := p.s.ScanRest()
+		if lit == "" {
+			return syntaxErr(pos, "@Default missing value")
+		}
+		param.Default = lit
+		param.DefaultPos = pos
+	case Placeholder:
+		if err := p.scanRestText(&param.Placeholder); err != nil {
+			return err
+		}
+	case Required:
+		if tok, lit := p.scanIgnoreWs(); tok != EOL && tok != EOF {
+			return syntaxErr(p.pos(), "expected EOL, found %q", lit)
+		}
+		p.unscan()
+		param.Required = true
+	case Editor:
+		tok, lit := p.scanIdent()
+		if tok != Ident {
+			return syntaxErr(p.pos(), "expected Ident, found %q", lit)
+		}
+		param.Editor = lit
+		param.EditorPos = pos
 	}
-	param.Type = lit // TODO: Check types in the future.
+	return nil
+}
 
-	tok, lit = p.scanText()
-	if tok != Text {
-		return fmt.Errorf("Expected Text, found %q (%q). File: %s", lit, tok, d.fn)
+// scanReturn reads a @Return, the result type optionally followed by the
+// table mode.
+func (p *Parser) scanReturn(d *Document) error {
+	tok, lit := p.scanIdent()
+	if tok != Ident {
+		return syntaxErr(p.pos(), "expected Ident, found %q", lit)
+	}
+	var err error
+	if d.Return, err = ParseResultType(lit); err != nil {
+		return syntaxErr(p.pos(), "%s", err)
 	}
-	param.Description = lit
-	d.Params = append(d.Params, param)
 
+	if tok, lit = p.scanIdent(); tok != Ident {
+		p.unscan()
+		return nil
+	}
+	if d.ReturnMode, err = ParseTableMode(lit); err != nil {
+		return syntaxErr(p.pos(), "%s", err)
+	}
 	return nil
 }
 
-func (p *Parser) scanReturn(d *Document) error {
-	tok, lit := p.scanIgnoreWs()
+func (p *Parser) scanInvokable(d *Document) error {
+	tok, lit := p.scanIdent()
 	if tok != Ident {
-		return fmt.Errorf("Expected Ident, found %q (%q). File: %s", lit, tok, d.fn)
+		return syntaxErr(p.pos(), "expected Ident, found %q", lit)
+	}
+	var err error
+	if d.Invokable, err = ParseInvokeType(lit); err != nil {
+		return syntaxErr(p.pos(), "%s", err)
 	}
-	d.Return = lit
 	return nil
 }
 
 func (p *Parser) scanColumn(d *Document) error {
+	return p.scanParameter(&d.Columns)
+}
+
+// scanParameter reads the name, type and description of a parameter, column,
+// attribute or config, and adds it to dst.
+func (p *Parser) scanParameter(dst *[]*Parameter) error {
 	param := &Parameter{}
-	tok, lit := p.scanIgnoreWs()
+	tok, lit := p.scanIdent()
 	if tok != Ident {
-		return fmt.Errorf("Expected Ident, found %q (%q). File: %s", lit, tok, d.fn)
+		return syntaxErr(p.pos(), "expected Ident, found %q", lit)
 	}
 	param.Name = lit
+	param.Pos = p.pos()
 
 	tok, lit = p.scanTypeIgnoreWs()
 	if tok != TypeIdent {
-		return fmt.Errorf("Expected Ident, found %q (%q). File: %s", lit, tok, d.fn)
+		return syntaxErr(p.pos(), "expected Ident, found %q", lit)
 	}
-	param.Type = lit // TODO: Check types in the future.
+	param.Type = p.parseType(lit)
 
 	tok, lit = p.scanText()
 	if tok != Text {
-		return fmt.Errorf("Expected Text, found %q (%q). File: %s", lit, tok, d.fn)
+		return syntaxErr(p.pos(), "expected Text, found %q", lit)
 	}
 	param.Description = lit
-	d.Columns = append(d.Columns, param)
+	*dst = append(*dst, param)
 
 	return nil
 }
@@ -361,9 +733,9 @@ func (p *Parser) scanColumn(d *Document) error {
 func (p *Parser) scanValue(d *Document) error {
 	tok, lit := p.scanTypeIgnoreWs()
 	if tok != TypeIdent {
-		return fmt.Errorf("Expected Ident, found %q (%q). File: %s", lit, tok, d.fn)
+		return syntaxErr(p.pos(), "expected Ident, found %q", lit)
 	}
-	d.ValueType = lit
+	d.ValueType = p.parseType(lit)
 
 	tok, lit = p.scanIgnoreWs()
 	if tok == Ident {
@@ -377,6 +749,27 @@ func (p *Parser) scanValue(d *Document) error {
 	return nil
 }
 
+// scanRestText scans the remaining text of the line into dst, reporting a
+// syntax error if there is none.
+func (p *Parser) scanRestText(dst *string) error {
+	tok, lit := p.scanText()
+	if tok != Text || lit == "" {
+		return syntaxErr(p.pos(), "expected Text, found %q", lit)
+	}
+	*dst = lit
+	return nil
+}
+
+// parseType parses the type ident lit. An invalid type is reported without
+// interrupting the annotation being scanned.
+func (p *Parser) parseType(lit string) types.Type {
+	t, err := types.Parse(lit)
+	if err != nil {
+		p.diags = append(p.diags, newDiag(ErrorSeverity, p.pos(), CodeInvalidType, "%s", err))
+	}
+	return t
+}
+
 func (p *Parser) maybeEol() {
 	if tok, _ := p.scan(); tok != EOL {
 		p.unscan()
@@ -1,7 +1,11 @@
 package parser
 
 import (
+	"reflect"
 	"testing"
+
+	"github.com/butlermatt/dsdoc/trim"
+	"github.com/butlermatt/dsdoc/types"
 )
 
 func TestParser_Parse(t *testing.T) {
@@ -35,24 +39,24 @@ func TestParser_Parse(t *testing.T) {
 				Is:         "addDevice",
 				ParentName: "root",
 				Short:      "Adds a Device to the link",
-				Long:       "This is a long description. It really doesn't contain anything special. But it is multiline",
+				Long:       Blocks{{Text: "This is a long description. It really doesn't contain anything special. But it is multiline"}},
 				Params: []*Parameter{
 					{
 						Name:        "deviceName",
-						Type:        "string",
+						Type:        types.Type{Kind: types.String},
 						Description: "Name of the device to add. It will appear as a node on the root of the link.",
 					},
 					{
 						Name:        "username",
-						Type:        "string",
+						Type:        types.Type{Kind: types.String},
 						Description: "The Username to access the device.",
 					},
 				},
-				Return: "value",
+				Return: ValuesResult,
 				Columns: []*Parameter{
 					{
 						Name:        "success",
-						Type:        "bool",
+						Type:        types.Type{Kind: types.Bool},
 						Description: "Returns true on success. False otherwise.",
 					},
 				},
@@ -73,7 +77,7 @@ func TestParser_Parse(t *testing.T) {
 				Name:       "test",
 				ParentName: "root",
 				Short:      "Short Test node",
-				Long:       "Also has a long description. But no value.",
+				Long:       Blocks{{Text: "Also has a long description. But no value."}},
 			},
 		},
 		{
@@ -90,14 +94,14 @@ func TestParser_Parse(t *testing.T) {
 				Name:       "version",
 				ParentName: "root",
 				Short:      "Short version description",
-				ValueType:  "string",
+				ValueType:  types.Type{Kind: types.String},
 			},
 		},
 	}
 
 	for i, tt := range tests {
 		parser := NewParser()
-		err := parser.Parse(tt.s, "testfile.go")
+		err := parser.Parse(trim.Batch{File: "testfile.go", Lines: tt.s})
 		if err != nil {
 			t.Errorf("%d. Unexpected error %q", i, err)
 		}
@@ -130,7 +134,7 @@ func TestParser_Parse(t *testing.T) {
 			t.Errorf("%d. Short Description does not match:\n  exp=%q\n  got=%q\n", i, tt.doc.Short, d.Short)
 		}
 
-		if d.Long != tt.doc.Long {
+		if !reflect.DeepEqual(d.Long, tt.doc.Long) {
 			t.Errorf("%d. Long Description does not match:\n  exp=%q\n  got=%q\n", i, tt.doc.Long, d.Long)
 		}
 
@@ -140,7 +144,7 @@ func TestParser_Parse(t *testing.T) {
 			}
 			for j, p := range tt.doc.Params {
 				tp := d.Params[j]
-				if p.Type != tp.Type {
+				if !p.Type.Equal(tp.Type) {
 					t.Errorf("%d. Param %d. Param type mismatch: exp=%q got=%q", i, j, p.Type, tp.Type)
 				}
 				if p.Name != tp.Name {
@@ -164,7 +168,7 @@ func TestParser_Parse(t *testing.T) {
 			}
 			for j, p := range tt.doc.Columns {
 				tp := d.Columns[i]
-				if p.Type != tp.Type {
+				if !p.Type.Equal(tp.Type) {
 					t.Errorf("%d. Column %d. Column type mismatch: exp=%q got=%q", i, j, p.Type, tp.Type)
 				}
 				if p.Name != tp.Name {
@@ -178,7 +182,7 @@ func TestParser_Parse(t *testing.T) {
 			t.Errorf("%d. Expect 0 columns, found=%d", i, len(d.Columns))
 		}
 
-		if d.ValueType != tt.doc.ValueType {
+		if !d.ValueType.Equal(tt.doc.ValueType) {
 			t.Errorf("%d. Value type does not match: exp=%q got=%q", i, tt.doc.ValueType, d.ValueType)
 		}
 
@@ -232,7 +236,7 @@ func TestParser_Build(t *testing.T) {
 
 	p := NewParser()
 	for i, tt := range tests {
-		err := p.Parse(tt.s, "testfile.go")
+		err := p.Parse(trim.Batch{File: "testfile.go", Lines: tt.s})
 		if err != nil {
 			t.Errorf("%d. Unexpected error parsing: %q", i, err)
 		}
@@ -270,3 +274,558 @@ func getTest(tests []testStruct, name string) *Document {
 	}
 	return nil
 }
+
+func TestParser_Position(t *testing.T) {
+	src := []string{
+		`//* @Action Add_Device`,
+		`//* @Parent root`,
+		`//*`,
+		`//* Adds a device.`,
+		`//*`,
+		`//* @Param url string The URL of the device.`,
+	}
+	p := NewParser()
+	for _, b := range trim.TrimDsDoc(src, "lib/src/device.dart") {
+		if err := p.Parse(b); err != nil {
+			t.Fatalf("Unexpected error %q", err)
+		}
+	}
+	doc, err := p.Build()
+	if err != nil {
+		t.Fatalf("Unexpected build error %q", err)
+	}
+	d := doc.Children[0]
+	if exp := (Position{File: "lib/src/device.dart", Line: 1, Col: 5}); d.Pos != exp {
+		t.Errorf("Doc position mismatch: exp=%v got=%v", exp, d.Pos)
+	}
+	if exp := (Position{File: "lib/src/device.dart", Line: 6, Col: 12}); d.Params[0].Pos != exp {
+		t.Errorf("Param position mismatch: exp=%v got=%v", exp, d.Params[0].Pos)
+	}
+}
+
+// Ensure docs from .dsdoc files and source files share parents.
+func TestParser_Standalone(t *testing.T) {
+	files := map[string][]string{
+		"lib/device.dart": {
+			`//* @Node device`,
+			`//* @Parent root`,
+		},
+		"nodes.dsdoc": {
+			`@Node config`,
+			`@Parent device`,
+			`---`,
+			`@Action Reload`,
+			`@Parent config`,
+		},
+	}
+	p := NewParser()
+	for _, path := range []string{"nodes.dsdoc", "lib/device.dart"} {
+		for _, b := range trim.TrimDsDoc(files[path], path) {
+			if err := p.Parse(b); err != nil {
+				t.Fatalf("%s. Unexpected error %q", path, err)
+			}
+		}
+	}
+	doc, err := p.Build()
+	if err != nil {
+		t.Fatalf("Unexpected build error %q", err)
+	}
+	if len(doc.Children) != 1 || len(doc.Children[0].Children) != 1 {
+		t.Fatalf("Unexpected tree: %v", doc.Children)
+	}
+	cfg := doc.Children[0].Children[0]
+	if cfg.Name != "config" || len(cfg.Children) != 1 || cfg.Children[0].Name != "Reload" {
+		t.Errorf("Unexpected config doc: %+v", cfg)
+	}
+	if exp := (Position{File: "nodes.dsdoc", Line: 4, Col: 1}); cfg.Children[0].Pos != exp {
+		t.Errorf("Position mismatch: exp=%v got=%v", exp, cfg.Children[0].Pos)
+	}
+}
+
+func TestParser_ErrorPosition(t *testing.T) {
+	var tests = []struct {
+		s   []string
+		err string
+	}{
+		{
+			s: []string{
+				`@Node version`,
+				`@Parent root`,
+				`@Is .bad`,
+			},
+			err: "lib/node.dart:42:9: error: expected Ident, found \".\" [syntax]",
+		},
+		{
+			s: []string{
+				`@Node version`,
+				`@Is versionNode`,
+			},
+			err: "lib/node.dart:40:5: error: DsDoc missing required Parent field [missing-parent]",
+		},
+		{
+			s: []string{
+				`@Node version`,
+				`@Bogus`,
+				`@Parent root`,
+			},
+			err: "lib/node.dart:41:6: error: unknown attribute: \"Bogus\" [unknown-attribute]",
+		},
+	}
+
+	for i, tt := range tests {
+		p := NewParser()
+		cols := make([]int, len(tt.s))
+		for j := range cols {
+			cols[j] = 5
+		}
+		err := p.Parse(trim.Batch{File: "lib/node.dart", Line: 40, Cols: cols, Lines: tt.s})
+		if err == nil {
+			t.Errorf("%d. Expected error %q", i, tt.err)
+		} else if err.Error() != tt.err {
+			t.Errorf("%d. Error mismatch:\n  exp=%q\n  got=%q", i, tt.err, err.Error())
+		}
+	}
+}
+
+func TestParser_Recover(t *testing.T) {
+	p := NewParser()
+	err := p.Parse(trim.Batch{File: "node.dart", Line: 1, Lines: []string{
+		`@Node version`,
+		`@Is .bad`,
+		`@Bogus value`,
+		`@Parent root`,
+		``,
+		`Short description`,
+		``,
+		`@Value string`,
+	}})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	var exp = []struct {
+		code Code
+		line int
+	}{
+		{code: CodeSyntax, line: 2},
+		{code: CodeUnknownAttr, line: 3},
+	}
+	ds := p.Diagnostics()
+	if len(ds) != len(exp) {
+		t.Fatalf("Diagnostic counts do not match: exp=%d got=%d\n%v", len(exp), len(ds), ds)
+	}
+	for i, e := range exp {
+		if ds[i].Code != e.code {
+			t.Errorf("%d. Code mismatch: exp=%q got=%q", i, e.code, ds[i].Code)
+		}
+		if ds[i].Pos.Line != e.line {
+			t.Errorf("%d. Line mismatch: exp=%d got=%d", i, e.line, ds[i].Pos.Line)
+		}
+	}
+
+	// Annotations following the errors should still be parsed.
+	doc, _ := p.Build()
+	if len(doc.Children) != 1 {
+		t.Fatalf("Expected 1 child, found %d", len(doc.Children))
+	}
+	d := doc.Children[0]
+	if d.Short != "Short description" {
+		t.Errorf("Short description mismatch: got=%q", d.Short)
+	}
+	if d.ValueType.Kind != types.String {
+		t.Errorf("Value type mismatch: got=%q", d.ValueType)
+	}
+}
+
+func TestParser_BuildDiagnostics(t *testing.T) {
+	var batches = [][]string{
+		{`@Node one`, `@Parent missing`, ``, `One`},
+		{`@Node two`, `@Parent alsoMissing`, ``, `Two`},
+		{`@Node one`, `@Parent root`, ``, `Duplicate one`},
+	}
+
+	p := NewParser()
+	for i, b := range batches {
+		p.Parse(trim.Batch{File: "node.dart", Line: i*10 + 1, Lines: b})
+	}
+	_, err := p.Build()
+	if err == nil {
+		t.Fatal("Expected a build error")
+	}
+
+	ds := p.Diagnostics()
+	ds.Sort()
+	var exp = []struct {
+		code Code
+		line int
+	}{
+		{code: CodeUnknownParent, line: 1},
+		{code: CodeUnknownParent, line: 11},
+		{code: CodeDuplicate, line: 21},
+	}
+	if len(ds) != len(exp) {
+		t.Fatalf("Diagnostic counts do not match: exp=%d got=%d\n%v", len(exp), len(ds), ds)
+	}
+	for i, e := range exp {
+		if ds[i].Code != e.code || ds[i].Pos.Line != e.line {
+			t.Errorf("%d. Diagnostic mismatch: exp=%s at line %d, got=%s at line %d", i, e.code, e.line, ds[i].Code, ds[i].Pos.Line)
+		}
+	}
+}
+
+func TestParser_Link(t *testing.T) {
+	p := NewParser()
+	var batches = [][]string{
+		{`@Node version`, `@Parent root`, ``, `Version node`},
+		{
+			`@Link Example_Link`,
+			`@Version 1.2.0`,
+			`@Author Jane Doe <jane@example.com>`,
+			`@License Apache-2.0`,
+			`@Homepage https://example.com/link`,
+			``,
+			`An example link.`,
+		},
+		{`@Action Reset`, `@Parent root`, ``, `Resets the link`},
+	}
+	for i, b := range batches {
+		if err := p.Parse(trim.Batch{File: "link.dart", Line: i*10 + 1, Lines: b}); err != nil {
+			t.Fatalf("%d. Unexpected error %q", i, err)
+		}
+	}
+	doc, err := p.Build()
+	if err != nil {
+		t.Fatalf("Unexpected build error %q", err)
+	}
+
+	if doc.Type != LinkDoc {
+		t.Errorf("Root type mismatch: exp=%q got=%q", LinkDoc, doc.Type)
+	}
+	if doc.Name != "Example_Link" {
+		t.Errorf("Link name mismatch: got=%q", doc.Name)
+	}
+	if doc.Version != "1.2.0" || doc.Author != "Jane Doe <jane@example.com>" ||
+		doc.License != "Apache-2.0" || doc.Homepage != "https://example.com/link" {
+		t.Errorf("Link metadata mismatch: got=%q %q %q %q", doc.Version, doc.Author, doc.License, doc.Homepage)
+	}
+	if doc.Short != "An example link." {
+		t.Errorf("Short description mismatch: got=%q", doc.Short)
+	}
+	if len(doc.Children) != 2 {
+		t.Fatalf("Expected 2 children, found %d", len(doc.Children))
+	}
+	for _, ch := range doc.Children {
+		if ch.Parent != doc {
+			t.Errorf("Child %q is not attached to the link", ch.Name)
+		}
+	}
+}
+
+func TestParser_LinkErrors(t *testing.T) {
+	p := NewParser()
+	p.Parse(trim.Batch{File: "link.dart", Line: 1, Lines: []string{`@Link One`}})
+	p.Parse(trim.Batch{File: "link.dart", Line: 5, Lines: []string{`@Link Two`, `@Parent root`}})
+	p.Parse(trim.Batch{File: "other.dart", Line: 1, Lines: []string{`@Link Three`, `@Parent root`}})
+
+	var exp = []Code{CodeDuplicate, CodeDuplicate}
+	ds := p.Diagnostics()
+	if len(ds) != len(exp) {
+		t.Fatalf("Diagnostic counts do not match: exp=%d got=%d\n%v", len(exp), len(ds), ds)
+	}
+	for i, c := range exp {
+		if ds[i].Code != c {
+			t.Errorf("%d. Code mismatch: exp=%q got=%q", i, c, ds[i].Code)
+		}
+	}
+
+	p = NewParser()
+	p.Parse(trim.Batch{File: "link.dart", Line: 1, Lines: []string{`@Link One`, `@Parent root`}})
+	if ds := p.Diagnostics(); len(ds) != 1 || ds[0].Code != CodeLinkParent {
+		t.Errorf("Expected a %q diagnostic, got %v", CodeLinkParent, ds)
+	}
+}
+
+func TestParser_Types(t *testing.T) {
+	p := NewParser()
+	err := p.Parse(trim.Batch{File: "node.dart", Line: 1, Lines: []string{
+		`@Action Set_Mode`,
+		`@Parent root`,
+		``,
+		`Sets the mode.`,
+		``,
+		`@Param mode enum[Low,Medium,High] The new mode.`,
+		`@Param level numbr The new level.`,
+		`@Column result enum[] The result.`,
+	}})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	ds := p.Diagnostics()
+	var exp = []int{7, 8}
+	if len(ds) != len(exp) {
+		t.Fatalf("Diagnostic counts do not match: exp=%d got=%d\n%v", len(exp), len(ds), ds)
+	}
+	for i, line := range exp {
+		if ds[i].Code != CodeInvalidType || ds[i].Pos.Line != line {
+			t.Errorf("%d. Expected %s at line %d, got %s at line %d", i, CodeInvalidType, line, ds[i].Code, ds[i].Pos.Line)
+		}
+	}
+
+	doc, _ := p.Build()
+	d := doc.Children[0]
+	if len(d.Params) != 2 {
+		t.Fatalf("Expected 2 params, found %d", len(d.Params))
+	}
+	exp2 := types.Type{Kind: types.Enum, Options: []string{"Low", "Medium", "High"}}
+	if !d.Params[0].Type.Equal(exp2) {
+		t.Errorf("Param type mismatch: exp=%v got=%v", exp2, d.Params[0].Type)
+	}
+	if d.Params[1].Description != "The new level." {
+		t.Errorf("Param description mismatch: got=%q", d.Params[1].Description)
+	}
+}
+
+// Ensure fragments merged in the same order build the same tree as Parse.
+func TestParser_Merge(t *testing.T) {
+	batches := []trim.Batch{
+		{File: "b.dart", Line: 1, Lines: []string{`@Action reset`, `@Parent device`}},
+		{File: "a.dart", Line: 1, Lines: []string{`@Node device`, `@Parent root`}},
+		{File: "a.dart", Line: 5, Lines: []string{`@Node device`, `@Parent root`}},
+		{File: "c.dart", Line: 1, Lines: []string{`@Bad`}},
+	}
+
+	p := NewParser()
+	for _, b := range batches {
+		p.Parse(b)
+	}
+
+	frags := []*Fragment{ParseFragment(batches[0]), ParseFragment(batches[1:3]...), ParseFragment(batches[3])}
+	if len(frags[1].Docs) != 2 || len(frags[2].Diags) != 1 {
+		t.Fatalf("Unexpected fragments: %+v %+v", frags[1], frags[2])
+	}
+	q := NewParser()
+	for i, f := range frags {
+		err := q.Merge(f)
+		if (err != nil) != (i > 0) {
+			t.Errorf("%d. Unexpected merge result %v", i, err)
+		}
+	}
+
+	if a, b := p.Diagnostics().Error(), q.Diagnostics().Error(); a != b {
+		t.Errorf("Diagnostics mismatch:\nparse=%s\nmerge=%s", a, b)
+	}
+	pd, _ := p.Build()
+	qd, _ := q.Build()
+	if len(pd.Children) != 1 || len(qd.Children) != 1 || len(qd.Children[0].Children) != 1 {
+		t.Errorf("Tree mismatch: parse=%v merge=%v", pd.Children, qd.Children)
+	}
+
+	// Merging the same fragments again builds the same tree.
+	link := ParseFragment(trim.Batch{File: "l.dart", Line: 1, Lines: []string{`@Link Example`, `@Parent root`}})
+	frags = append(frags, link)
+	for i := 0; i < 2; i++ {
+		r := NewParser()
+		for _, f := range frags {
+			r.Merge(f)
+		}
+		rd, _ := r.Build()
+		if rd.Name != "Example" || len(rd.Children) != 1 || len(rd.Children[0].Children) != 1 {
+			t.Errorf("%d. Tree mismatch after re-merge: %v", i, rd.Children)
+		}
+		if n := r.Diagnostics().Count(ErrorSeverity); n != 3 {
+			t.Errorf("%d. Expected 3 errors after re-merge, found %d: %v", i, n, r.Diagnostics())
+		}
+	}
+}
+
+func TestParser_Long(t *testing.T) {
+	src := []string{
+		`//* @Action Connect`,
+		`//* @Parent root`,
+		`//*`,
+		`//* Connects to a device.`,
+		`//*`,
+		`//* Retries until it`,
+		`//* succeeds.`,
+		`//*`,
+		`//* - tcp`,
+		`//* - tls`,
+		`//*`,
+		`//*     connect(url);`,
+		`//*       close();`,
+		`//*`,
+		`//* @Return value`,
+		`//*`,
+		`//* After attributes.`,
+	}
+	exp := Blocks{
+		{Text: "Retries until it succeeds."},
+		{Kind: ListBlock, Items: []string{"tcp", "tls"}},
+		{Kind: CodeBlock, Text: "connect(url);\n  close();"},
+		{Text: "After attributes."},
+	}
+
+	p := NewParser()
+	for _, b := range trim.TrimDsDoc(src, "device.dart") {
+		if err := p.Parse(b); err != nil {
+			t.Fatalf("Unexpected error %q", err)
+		}
+	}
+	doc, _ := p.Build()
+	d := doc.Children[0]
+	if d.Short != "Connects to a device." || d.Return != ValuesResult {
+		t.Errorf("Unexpected document %+v", d)
+	}
+	if !reflect.DeepEqual(d.Long, exp) {
+		t.Errorf("Long mismatch:\nexp=%#v\ngot=%#v", exp, d.Long)
+	}
+}
+
+func TestParser_ParamMeta(t *testing.T) {
+	src := []string{
+		`@Action Connect`,
+		`@Parent root`,
+		``,
+		`Connects to a device.`,
+		``,
+		`@Param url string The url of the device, which`,
+		`may span lines.`,
+		`@Required`,
+		`@Placeholder tcp://host:port`,
+		`@Param retries int How often to retry.`,
+		`@Default 3`,
+		`@Param notes string Notes.`,
+		`@Editor textarea`,
+	}
+	p := NewParser()
+	if err := p.Parse(trim.Batch{File: "c.dart", Line: 1, Lines: src}); err != nil {
+		t.Fatalf("Unexpected error %q", err)
+	}
+	doc, _ := p.Build()
+	ps := doc.Children[0].Params
+	if len(ps) != 3 {
+		t.Fatalf("Expected 3 params, found %d", len(ps))
+	}
+	if ps[0].Description != "The url of the device, which may span lines." || !ps[0].Required || ps[0].Placeholder != "tcp://host:port" {
+		t.Errorf("Param mismatch: %+v", ps[0])
+	}
+	if ps[1].Default != "3" || ps[1].Required || ps[1].Placeholder != "" {
+		t.Errorf("Param mismatch: %+v", ps[1])
+	}
+	if ps[2].Editor != "textarea" || ps[2].Default != "" {
+		t.Errorf("Param mismatch: %+v", ps[2])
+	}
+	if diags := Validate(doc); len(diags) != 0 {
+		t.Errorf("Unexpected validation problems:\n%v", diags)
+	}
+}
+
+func TestParser_ParamMetaErrors(t *testing.T) {
+	var tests = []struct {
+		lines []string
+		code  Code
+		pos   string
+	}{
+		{[]string{`@Default 1`}, CodeSyntax, "e.dart:5:2"},
+		{[]string{`@Param a int A.`, `@Return values`, `@Default 1`}, CodeSyntax, "e.dart:7:2"},
+		{[]string{`@Column a int A.`, `@Default 1`}, CodeSyntax, "e.dart:6:2"},
+		{[]string{`@Param a int A.`, ``, `More text.`, `@Default 1`}, CodeSyntax, "e.dart:8:2"},
+		{[]string{`@Param a int A.`, `@Default`}, CodeSyntax, "e.dart:6:2"},
+		{[]string{`@Param a int A.`, `@Required yes`}, CodeSyntax, "e.dart:6:11"},
+		{[]string{`@Param a int A.`, `@Default one`}, CodeInvalidDefault, "e.dart:6:2"},
+		{[]string{`@Param a enum[x,y] A.`, `@Default z`}, CodeInvalidDefault, "e.dart:6:2"},
+		{[]string{`@Param a string A.`, `@Editor fancy`}, CodeInvalidEditor, "e.dart:6:2"},
+		{[]string{`@Param a string A.`, `@Required`, `@Editor fancy`}, CodeInvalidEditor, "e.dart:7:2"},
+	}
+
+	for i, tt := range tests {
+		lines := append([]string{`@Action Act`, `@Parent root`, ``, `Acts.`}, tt.lines...)
+		p := NewParser()
+		p.Parse(trim.Batch{File: "e.dart", Line: 1, Lines: lines})
+		doc, _ := p.Build()
+		ds := append(p.Diagnostics(), Validate(doc)...)
+		if len(ds) != 1 {
+			t.Errorf("%d. Expected 1 diagnostic, found:\n%v", i, ds)
+			continue
+		}
+		if ds[0].Code != tt.code || ds[0].Pos.String() != tt.pos {
+			t.Errorf("%d. Diagnostic mismatch: exp=%s [%s] got=%s", i, tt.pos, tt.code, ds[0])
+		}
+	}
+}
+
+func TestParser_Return(t *testing.T) {
+	var tests = []struct {
+		line   string
+		ret    ResultType
+		mode   TableMode
+		invoke InvokeType
+		err    string
+	}{
+		{line: `@Return value`, ret: ValuesResult},
+		{line: `@Return table`, ret: TableResult},
+		{line: `@Return stream refresh`, ret: StreamResult, mode: RefreshMode},
+		{line: `@Return table append`, ret: TableResult, mode: AppendMode},
+		{line: `@Invokable config`, invoke: InvokeConfig},
+		{line: `@Return rows`, err: `r.dart:5:9: error: unknown result type "rows", expected values, table or stream [syntax]`},
+		{line: `@Return table merge`, err: `r.dart:5:15: error: unknown table mode "merge", expected append, refresh or stream [syntax]`},
+		{line: `@Invokable admin`, err: `r.dart:5:12: error: unknown invoke permission "admin", expected read, write, config or never [syntax]`},
+	}
+
+	for i, tt := range tests {
+		p := NewParser()
+		err := p.Parse(trim.Batch{File: "r.dart", Line: 1, Lines: []string{`@Action Act`, `@Parent root`, ``, `Acts.`, tt.line}})
+		var es string
+		if err != nil {
+			es = err.Error()
+		}
+		if es != tt.err {
+			t.Errorf("%d. Error mismatch:\nexp=%q\ngot=%q", i, tt.err, es)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		doc, _ := p.Build()
+		d := doc.Children[0]
+		if d.Return != tt.ret || d.ReturnMode != tt.mode || d.Invokable != tt.invoke {
+			t.Errorf("%d. Mismatch: exp=%v %v %v got=%v %v %v", i, tt.ret, tt.mode, tt.invoke, d.Return, d.ReturnMode, d.Invokable)
+		}
+	}
+}
+
+func TestParser_Attributes(t *testing.T) {
+	src := []string{
+		`@Node device`,
+		`@Parent root`,
+		``,
+		`A device.`,
+		``,
+		`@Attribute location string Where the device`,
+		`is installed.`,
+		`@Attribute floor int`,
+		`@Config permission enum[read,write] The permission.`,
+	}
+	p := NewParser()
+	if err := p.Parse(trim.Batch{File: "d.dart", Line: 1, Lines: src}); err != nil {
+		t.Fatalf("Unexpected error %q", err)
+	}
+	doc, _ := p.Build()
+	d := doc.Children[0]
+	if len(d.Attributes) != 2 || len(d.Configs) != 1 {
+		t.Fatalf("Expected 2 attributes and 1 config, found %d and %d", len(d.Attributes), len(d.Configs))
+	}
+	if a := d.Attributes[0]; a.Name != "location" || a.Type.Kind != types.String || a.Description != "Where the device is installed." {
+		t.Errorf("Attribute mismatch: %+v", a)
+	}
+	if a := d.Attributes[1]; a.Name != "floor" || a.Type.Kind != types.Int || a.Description != "" || a.Pos.Line != 8 {
+		t.Errorf("Attribute mismatch: %+v", a)
+	}
+	if c := d.Configs[0]; c.Name != "permission" || c.Type.Kind != types.Enum || c.Description != "The permission." {
+		t.Errorf("Config mismatch: %+v", c)
+	}
+	if diags := Validate(doc); len(diags) != 0 {
+		t.Errorf("Unexpected validation problems:\n%v", diags)
+	}
+}
@@ -0,0 +1,44 @@
+package parser
+
+import "fmt"
+
+// Position describes a location in a source file.
+type Position struct {
+	File string `json:"file"` // path of the file, relative to the scanned root
+	Line int    `json:"line"` // line number, starting at 1
+	Col  int    `json:"col"`  // column number in bytes, starting at 1
+}
+
+// IsValid reports whether the position has a line number.
+func (p Position) IsValid() bool { return p.Line > 0 }
+
+// String returns the position in the form file:line:col, omitting any
+// parts which are unknown.
+func (p Position) String() string {
+	s := p.File
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d", p.Line)
+		if p.Col > 0 {
+			s += fmt.Sprintf(":%d", p.Col)
+		}
+	}
+	if s == "" {
+		s = "-"
+	}
+	return s
+}
+
+// Before reports whether p comes before o, ordering by file, then line, then
+// column.
+func (p Position) Before(o Position) bool {
+	if p.File != o.File {
+		return p.File < o.File
+	}
+	if p.Line != o.Line {
+		return p.Line < o.Line
+	}
+	return p.Col < o.Col
+}
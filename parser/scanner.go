@@ -2,7 +2,10 @@ package parser
 
 import (
 	"bytes"
+	"strings"
 	"unicode/utf8"
+
+	"github.com/butlermatt/dsdoc/trim"
 )
 
 const (
@@ -20,6 +23,13 @@ type Scanner struct {
 	start int
 	pos   int
 	width int
+
+	// Position tracking for the start of the last token.
+	file      string
+	line0     int
+	cols      []int
+	indents   []int
+	startLine int
 }
 
 // read will return the next rune in the input.
@@ -45,6 +55,9 @@ func (s *Scanner) read() rune {
 // unread places the previous rune back in the reader.
 func (s *Scanner) unread() {
 	if s.pos == 0 {
+		if s.line == 0 {
+			return
+		}
 		s.line--
 		s.pos = len(s.in[s.line])
 	} else {
@@ -52,8 +65,23 @@ func (s *Scanner) unread() {
 	}
 }
 
+// Pos returns the position of the start of the last scanned token.
+func (s *Scanner) Pos() Position {
+	col := s.start + 1
+	if s.startLine < len(s.cols) {
+		col += s.cols[s.startLine] - 1
+	}
+	return Position{File: s.file, Line: s.line0 + s.startLine, Col: col}
+}
+
+// mark records the current location as the start of a token.
+func (s *Scanner) mark() {
+	s.startLine, s.start = s.line, s.pos
+}
+
 // Scan returns the next token and the literal value.
 func (s *Scanner) Scan() (ItemToken, string) {
+	s.mark()
 	r := s.read()
 
 	if isWs(r) {
@@ -83,6 +111,13 @@ func (s *Scanner) ScanText() (ItemToken, string) {
 	r := s.read()
 	for ; isWs(r); r = s.read() {
 	}
+	s.unread()
+	s.mark()
+	r = s.read()
+	if r == eof || r == eol {
+		s.unread()
+		return Text, ""
+	}
 
 	buf.WriteRune(r)
 
@@ -105,6 +140,64 @@ func (s *Scanner) ScanText() (ItemToken, string) {
 	return Text, buf.String()
 }
 
+// ScanLines returns a Text token holding the lines from the current line up
+// to the next line starting with an attribute, or the end of the input. The
+// lines keep their indentation and are joined by newlines. Trailing blank
+// lines are not included.
+func (s *Scanner) ScanLines() (ItemToken, string) {
+	s.mark()
+	var lines []string
+	for ; s.line < len(s.in); s.line, s.pos = s.line+1, 0 {
+		l := s.in[s.line][s.pos:]
+		if strings.HasPrefix(strings.TrimSpace(l), string(AttrChar)) {
+			break
+		}
+		if s.pos == 0 && s.line < len(s.indents) {
+			l = strings.Repeat(" ", s.indents[s.line]) + l
+		}
+		lines = append(lines, l)
+	}
+	s.width = 0
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return Text, strings.Join(lines, "\n")
+}
+
+// ScanRest returns a Text token holding the remainder of the current line,
+// without surrounding whitespace.
+func (s *Scanner) ScanRest() (ItemToken, string) {
+	s.mark()
+	if s.line >= len(s.in) {
+		return Text, ""
+	}
+	l := s.in[s.line][s.pos:]
+	s.start += len(l) - len(strings.TrimLeft(l, " \t"))
+	s.pos = len(s.in[s.line])
+	s.width = 0
+	return Text, strings.TrimSpace(l)
+}
+
+// acceptLine consumes the current line if it holds only l, and reports
+// whether it did.
+func (s *Scanner) acceptLine(l string) bool {
+	if s.line >= len(s.in) || s.pos != 0 || strings.TrimSpace(s.in[s.line]) != l {
+		return false
+	}
+	s.pos = len(s.in[s.line])
+	s.width = 0
+	return true
+}
+
+// linePos returns the position of the start of the content of line i.
+func (s *Scanner) linePos(i int) Position {
+	col := 1
+	if i < len(s.cols) {
+		col = s.cols[i]
+	}
+	return Position{File: s.file, Line: s.line0 + i, Col: col}
+}
+
 // scanWhitespace consumes all contiguous whitespace.
 func (s *Scanner) scanWhitespace() (ItemToken, string) {
 	var buf bytes.Buffer
@@ -134,6 +227,9 @@ func (s *Scanner) scanTypeIdent() (ItemToken, string) {
 	r := s.read()
 	for ; isWs(r); r = s.read() {
 	}
+	s.unread()
+	s.mark()
+	r = s.read()
 
 	buf.WriteRune(r)
 
@@ -205,6 +301,32 @@ func (s *Scanner) scanIdent() (ItemToken, string) {
 		return Column, buf.String()
 	case "Value":
 		return Value, buf.String()
+	case "Version":
+		return Version, buf.String()
+	case "Author":
+		return Author, buf.String()
+	case "License":
+		return License, buf.String()
+	case "Homepage":
+		return Homepage, buf.String()
+	case "Example":
+		return Example, buf.String()
+	case "EndExample":
+		return EndExample, buf.String()
+	case "Default":
+		return Default, buf.String()
+	case "Placeholder":
+		return Placeholder, buf.String()
+	case "Required":
+		return Required, buf.String()
+	case "Editor":
+		return Editor, buf.String()
+	case "Invokable":
+		return Invokable, buf.String()
+	case "Attribute":
+		return Attribute, buf.String()
+	case "Config":
+		return ConfigAttr, buf.String()
 	}
 
 	return Ident, buf.String()
@@ -219,7 +341,17 @@ func (s *Scanner) peak() rune {
 
 // NewScanner returns a new instance of Scanner.
 func NewScanner(in []string) *Scanner {
-	return &Scanner{in: in}
+	return &Scanner{in: in, line0: 1}
+}
+
+// NewBatchScanner returns a new instance of Scanner which reports positions
+// relative to where the batch was found in its source file.
+func NewBatchScanner(b trim.Batch) *Scanner {
+	s := &Scanner{in: b.Lines, file: b.File, line0: b.Line, cols: b.Cols, indents: b.Indents}
+	if s.line0 < 1 {
+		s.line0 = 1
+	}
+	return s
 }
 
 func isWs(ch rune) bool {
@@ -2,6 +2,8 @@ package parser
 
 import (
 	"testing"
+
+	"github.com/butlermatt/dsdoc/trim"
 )
 
 func TestScanner_Scan(t *testing.T) {
@@ -62,3 +64,49 @@ func TestScanner_ScanText(t *testing.T) {
 		}
 	}
 }
+
+func TestScanner_ScanLines(t *testing.T) {
+	s := NewBatchScanner(trim.Batch{
+		Lines:   []string{`First`, ``, `code`, ``, `@Param`},
+		Indents: []int{0, 0, 4, 0, 0},
+	})
+	tok, lit := s.ScanLines()
+	if tok != Text || lit != "First\n\n    code" {
+		t.Errorf("Lines mismatch: got=%q %q", tok, lit)
+	}
+	if tok, _ := s.Scan(); tok != Attr {
+		t.Errorf("Expected the attribute to follow, got %q", tok)
+	}
+}
+
+func TestScanner_Pos(t *testing.T) {
+	s := NewBatchScanner(trim.Batch{
+		File:  "node.dart",
+		Line:  10,
+		Cols:  []int{5, 12},
+		Lines: []string{`@Node version`, `@Parent root`},
+	})
+
+	var tests = []struct {
+		tok ItemToken
+		pos Position
+	}{
+		{tok: Attr, pos: Position{File: "node.dart", Line: 10, Col: 5}},
+		{tok: Node, pos: Position{File: "node.dart", Line: 10, Col: 6}},
+		{tok: WS, pos: Position{File: "node.dart", Line: 10, Col: 10}},
+		{tok: Ident, pos: Position{File: "node.dart", Line: 10, Col: 11}},
+		{tok: EOL, pos: Position{File: "node.dart", Line: 10, Col: 18}},
+		{tok: Attr, pos: Position{File: "node.dart", Line: 11, Col: 12}},
+		{tok: Parent, pos: Position{File: "node.dart", Line: 11, Col: 13}},
+	}
+
+	for i, tt := range tests {
+		tok, lit := s.Scan()
+		if tok != tt.tok {
+			t.Fatalf("%d. token mismatch: exp=%q got=%q <%q>", i, tt.tok, tok, lit)
+		}
+		if pos := s.Pos(); pos != tt.pos {
+			t.Errorf("%d. position mismatch: exp=%v got=%v", i, tt.pos, pos)
+		}
+	}
+}
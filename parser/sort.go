@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortOrder is the order in which the children of a document are listed.
+type SortOrder int
+
+const (
+	// SourceOrder lists children by file path, then by line.
+	SourceOrder SortOrder = iota
+	// AlphaOrder lists children alphabetically by name.
+	AlphaOrder
+	// ActionsFirst lists Actions before other children, each in source order.
+	ActionsFirst
+	// NodesFirst lists Nodes before Actions, each in source order.
+	NodesFirst
+)
+
+func (o SortOrder) String() string {
+	switch o {
+	case SourceOrder:
+		return "source"
+	case AlphaOrder:
+		return "alpha"
+	case ActionsFirst:
+		return "actions-first"
+	case NodesFirst:
+		return "nodes-first"
+	}
+	return ""
+}
+
+// ParseSortOrder returns the SortOrder named s.
+func ParseSortOrder(s string) (SortOrder, error) {
+	for _, o := range []SortOrder{SourceOrder, AlphaOrder, ActionsFirst, NodesFirst} {
+		if s == o.String() {
+			return o, nil
+		}
+	}
+	return SourceOrder, fmt.Errorf("unknown sort order %q, expected one of source, alpha, actions-first or nodes-first", s)
+}
+
+// SortedChildren returns the children of d in the order o. The Children of d
+// are not modified.
+func (d *Document) SortedChildren(o SortOrder) []*Document {
+	chs := make([]*Document, len(d.Children))
+	copy(chs, d.Children)
+
+	// Children are kept in source order by Build, so a stable sort retains
+	// it for any ties.
+	switch o {
+	case AlphaOrder:
+		sort.SliceStable(chs, func(i, j int) bool {
+			return strings.ToLower(chs[i].Name) < strings.ToLower(chs[j].Name)
+		})
+	case ActionsFirst:
+		sort.SliceStable(chs, func(i, j int) bool {
+			return chs[i].Type == ActionDoc && chs[j].Type != ActionDoc
+		})
+	case NodesFirst:
+		sort.SliceStable(chs, func(i, j int) bool {
+			return chs[i].Type != ActionDoc && chs[j].Type == ActionDoc
+		})
+	}
+	return chs
+}
+
+// sortChildren orders the children of d, and all of their descendants, by
+// their position in the source files.
+func (d *Document) sortChildren() {
+	sort.SliceStable(d.Children, func(i, j int) bool {
+		return d.Children[i].Pos.Before(d.Children[j].Pos)
+	})
+	for _, ch := range d.Children {
+		ch.sortChildren()
+	}
+}
@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/butlermatt/dsdoc/trim"
+)
+
+func TestDocument_SortedChildren(t *testing.T) {
+	// Batches are parsed out of source order, and b.dart is parsed before
+	// the parent of its children to ensure they are linked by Build.
+	var batches = []trim.Batch{
+		{File: "b.dart", Line: 20, Lines: []string{`@Node zeta`, `@Parent root`, ``, `Zeta`}},
+		{File: "b.dart", Line: 1, Lines: []string{`@Action Beta`, `@Parent root`, ``, `Beta`}},
+		{File: "a.dart", Line: 30, Lines: []string{`@Node alpha`, `@Parent root`, ``, `Alpha`}},
+		{File: "a.dart", Line: 10, Lines: []string{`@Action Gamma`, `@Parent root`, ``, `Gamma`}},
+		{File: "c.dart", Line: 5, Lines: []string{`@Node second`, `@Parent alpha`, ``, `Second`}},
+		{File: "c.dart", Line: 1, Lines: []string{`@Node first`, `@Parent alpha`, ``, `First`}},
+	}
+
+	var tests = []struct {
+		o   SortOrder
+		exp []string
+	}{
+		{o: SourceOrder, exp: []string{"Gamma", "alpha", "Beta", "zeta"}},
+		{o: AlphaOrder, exp: []string{"alpha", "Beta", "Gamma", "zeta"}},
+		{o: ActionsFirst, exp: []string{"Gamma", "Beta", "alpha", "zeta"}},
+		{o: NodesFirst, exp: []string{"alpha", "zeta", "Gamma", "Beta"}},
+	}
+
+	p := NewParser()
+	for i, b := range batches {
+		if err := p.Parse(b); err != nil {
+			t.Fatalf("%d. Unexpected error %q", i, err)
+		}
+	}
+	doc, err := p.Build()
+	if err != nil {
+		t.Fatalf("Unexpected build error %q", err)
+	}
+
+	for i, tt := range tests {
+		chs := doc.SortedChildren(tt.o)
+		if len(chs) != len(tt.exp) {
+			t.Fatalf("%d. Child counts do not match: exp=%d got=%d", i, len(tt.exp), len(chs))
+		}
+		for j, n := range tt.exp {
+			if chs[j].Name != n {
+				t.Errorf("%d. %s. Child %d mismatch: exp=%q got=%q", i, tt.o, j, n, chs[j].Name)
+			}
+		}
+	}
+
+	alpha := doc.Children[1]
+	if alpha.Children[0].Name != "first" || alpha.Children[1].Name != "second" {
+		t.Errorf("Grandchildren not in source order: got=%q, %q", alpha.Children[0].Name, alpha.Children[1].Name)
+	}
+}
+
+func TestParseSortOrder(t *testing.T) {
+	for _, o := range []SortOrder{SourceOrder, AlphaOrder, ActionsFirst, NodesFirst} {
+		got, err := ParseSortOrder(o.String())
+		if err != nil || got != o {
+			t.Errorf("%s. Unexpected result: got=%s err=%v", o, got, err)
+		}
+	}
+	if _, err := ParseSortOrder("random"); err == nil {
+		t.Error("Expected an error for an unknown sort order")
+	}
+}
@@ -42,8 +42,41 @@ const (
 	Column
 	// Value is a DsDoc attribute keyword.
 	Value
+	// Version is a DsDoc attribute keyword.
+	Version
+	// Author is a DsDoc attribute keyword.
+	Author
+	// License is a DsDoc attribute keyword.
+	License
+	// Homepage is a DsDoc attribute keyword.
+	Homepage
+	// Example is a DsDoc attribute keyword.
+	Example
+	// EndExample is a DsDoc attribute keyword.
+	EndExample
+	// Default is a DsDoc attribute keyword.
+	Default
+	// Placeholder is a DsDoc attribute keyword.
+	Placeholder
+	// Required is a DsDoc attribute keyword.
+	Required
+	// Editor is a DsDoc attribute keyword.
+	Editor
+	// Invokable is a DsDoc attribute keyword.
+	Invokable
+	// Attribute is a DsDoc attribute keyword.
+	Attribute
+	// ConfigAttr is the DsDoc attribute keyword Config, named so as not to
+	// clash with the Config WriteType.
+	ConfigAttr
 )
 
+// isKeyword reports whether the token is a DsDoc attribute keyword.
+func (i ItemToken) isKeyword() bool { return i >= Action }
+
+// isParamMeta reports whether the token is a keyword which adds to a @Param.
+func (i ItemToken) isParamMeta() bool { return i >= Default && i <= Editor }
+
 func (i ItemToken) String() string {
 	t := "UNKNOWN"
 	switch i {
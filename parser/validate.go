@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/butlermatt/dsdoc/types"
+)
+
+// Validate checks the documents in the tree below root for annotations which
+// are not valid for their DocType and for missing required values. It should
+// be called after Build.
+func Validate(root *Document) Diagnostics {
+	var ds Diagnostics
+	validateDoc(root, &ds)
+	return ds
+}
+
+func validateDoc(d *Document, ds *Diagnostics) {
+	report := func(sev Severity, code Code, format string, args ...interface{}) {
+		*ds = append(*ds, newDiag(sev, d.Pos, code, format, args...))
+	}
+	invalid := func(attr string) {
+		report(ErrorSeverity, CodeInvalidAnnotation, "@%s is not valid on %s DsDocs", attr, d.Type)
+	}
+
+	if d.Short == "" {
+		report(ErrorSeverity, CodeMissingShort, "DsDoc %q missing required Short description", d.Name)
+	}
+	if d.Type != LinkDoc && d.Path == "" && d.MetaName == "" {
+		report(ErrorSeverity, CodeMissingMetaType, "DsDoc without a path name requires a @MetaType")
+	}
+
+	if d.Type != ActionDoc {
+		if len(d.Params) > 0 {
+			invalid("Param")
+		}
+		if d.Return != NoResult {
+			invalid("Return")
+		}
+		if d.Invokable != InvokeUnset {
+			invalid("Invokable")
+		}
+		if len(d.Columns) > 0 {
+			invalid("Column")
+		}
+		if len(d.Examples) > 0 {
+			invalid("Example")
+		}
+	}
+	if d.Type != NodeDoc {
+		if d.ValueType.Kind != types.None {
+			invalid("Value")
+		}
+		if len(d.Attributes) > 0 {
+			invalid("Attribute")
+		}
+		if len(d.Configs) > 0 {
+			invalid("Config")
+		}
+	}
+	if d.Type != LinkDoc {
+		if d.Version != "" {
+			invalid("Version")
+		}
+		if d.Author != "" {
+			invalid("Author")
+		}
+		if d.License != "" {
+			invalid("License")
+		}
+		if d.Homepage != "" {
+			invalid("Homepage")
+		}
+	}
+
+	if d.Type == ActionDoc {
+		if d.Return == TableResult && len(d.Columns) == 0 {
+			report(WarningSeverity, CodeNoColumns, "Action %q returns a table but declares no @Column", d.Name)
+		}
+		if d.ReturnMode != NoMode && d.Return != TableResult && d.Return != StreamResult {
+			report(ErrorSeverity, CodeInvalidReturn, "Action %q has table mode %s, but returns %s rather than a table or stream", d.Name, d.ReturnMode, resultName(d.Return))
+		}
+		if len(d.Children) > 0 {
+			report(ErrorSeverity, CodeActionChildren, "Action %q cannot have children, found %d", d.Name, len(d.Children))
+		}
+		validateParams(d, ds)
+		validateExamples(d, ds)
+	}
+
+	for _, ch := range d.Children {
+		validateDoc(ch, ds)
+	}
+}
+
+// validateParams checks the @Default of each parameter of the Action d is a
+// value of the parameter's type, and that each @Editor is known.
+func validateParams(d *Document, ds *Diagnostics) {
+	for _, p := range d.Params {
+		if p.Default != "" {
+			if err := p.Type.Check(p.Default); err != nil {
+				*ds = append(*ds, newDiag(ErrorSeverity, p.metaPos(p.DefaultPos), CodeInvalidDefault, "@Default of parameter %q: %s", p.Name, err))
+			}
+		}
+		if p.Editor != "" && !isEditor(p.Editor) {
+			*ds = append(*ds, newDiag(ErrorSeverity, p.metaPos(p.EditorPos), CodeInvalidEditor, "unknown @Editor %q of parameter %q, expected one of %s", p.Editor, p.Name, strings.Join(Editors, ", ")))
+		}
+	}
+}
+
+// metaPos returns pos, the position of an annotation following the @Param
+// p, or the position of p if it is unknown.
+func (p *Parameter) metaPos(pos Position) Position {
+	if pos.IsValid() {
+		return pos
+	}
+	return p.Pos
+}
+
+// resultName returns the name of r for use in a message.
+func resultName(r ResultType) string {
+	if r == NoResult {
+		return "nothing"
+	}
+	return r.String()
+}
+
+func isEditor(s string) bool {
+	for _, e := range Editors {
+		if s == e {
+			return true
+		}
+	}
+	return false
+}
+
+// validateExamples checks the parameters and result columns of the examples
+// of the Action d are declared by its @Param and @Column annotations.
+func validateExamples(d *Document, ds *Diagnostics) {
+	params := make(map[string]bool)
+	for _, p := range d.Params {
+		params[p.Name] = true
+	}
+	cols := make(map[string]bool)
+	for _, c := range d.Columns {
+		cols[c.Name] = true
+	}
+
+	for _, ex := range d.Examples {
+		for _, p := range ex.Params {
+			if !params[p.Name] {
+				*ds = append(*ds, newDiag(ErrorSeverity, p.Pos, CodeExampleName, "example parameter %q is not a @Param of %q", p.Name, d.Name))
+			}
+		}
+		for _, c := range ex.Columns {
+			if !cols[c] {
+				*ds = append(*ds, newDiag(ErrorSeverity, ex.TablePos, CodeExampleName, "example column %q is not a @Column of %q", c, d.Name))
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/butlermatt/dsdoc/trim"
+)
+
+func TestValidate(t *testing.T) {
+	type diag struct {
+		sev  Severity
+		code Code
+	}
+	var tests = []struct {
+		s     [][]string
+		diags []diag
+	}{
+		{
+			s: [][]string{{
+				`@Node version`,
+				`@Parent root`,
+				``,
+				`A valid node.`,
+				``,
+				`@Value string`,
+			}},
+		},
+		{
+			s: [][]string{{
+				`@Node version`,
+				`@Parent root`,
+				``,
+				`Node with action annotations.`,
+				``,
+				`@Param name string The name.`,
+				`@Return value`,
+				`@Invokable write`,
+				`@Column success bool Success.`,
+			}},
+			diags: []diag{
+				{ErrorSeverity, CodeInvalidAnnotation},
+				{ErrorSeverity, CodeInvalidAnnotation},
+				{ErrorSeverity, CodeInvalidAnnotation},
+				{ErrorSeverity, CodeInvalidAnnotation},
+			},
+		},
+		{
+			s: [][]string{{
+				`@Action Get_Version`,
+				`@Parent root`,
+				`@Version 1.0.0`,
+				``,
+				`Action with a value.`,
+				``,
+				`@Value string`,
+				`@Attribute location string Location.`,
+				`@Config secret string Secret.`,
+			}},
+			diags: []diag{
+				{ErrorSeverity, CodeInvalidAnnotation},
+				{ErrorSeverity, CodeInvalidAnnotation},
+				{ErrorSeverity, CodeInvalidAnnotation},
+				{ErrorSeverity, CodeInvalidAnnotation},
+			},
+		},
+		{
+			s: [][]string{{
+				`@Node`,
+				`@Parent root`,
+			}},
+			diags: []diag{
+				{ErrorSeverity, CodeMissingShort},
+				{ErrorSeverity, CodeMissingMetaType},
+			},
+		},
+		{
+			s: [][]string{{
+				`@Action List`,
+				`@Parent root`,
+				``,
+				`Lists things.`,
+				``,
+				`@Return table`,
+			}},
+			diags: []diag{
+				{WarningSeverity, CodeNoColumns},
+			},
+		},
+		{
+			s: [][]string{{
+				`@Action Get`,
+				`@Parent root`,
+				``,
+				`Gets a value.`,
+				``,
+				`@Return values append`,
+			}},
+			diags: []diag{
+				{ErrorSeverity, CodeInvalidReturn},
+			},
+		},
+		{
+			s: [][]string{
+				{`@Action Reset`, `@Parent root`, ``, `Resets.`},
+				{`@Node child`, `@Parent Reset`, ``, `Child of an action.`},
+			},
+			diags: []diag{
+				{ErrorSeverity, CodeActionChildren},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		p := NewParser()
+		for j, b := range tt.s {
+			if err := p.Parse(trim.Batch{File: "test.dart", Line: j*10 + 1, Lines: b}); err != nil {
+				t.Fatalf("%d. Unexpected parse error %q", i, err)
+			}
+		}
+		doc, err := p.Build()
+		if err != nil {
+			t.Fatalf("%d. Unexpected build error %q", i, err)
+		}
+
+		ds := Validate(doc)
+		if len(ds) != len(tt.diags) {
+			t.Errorf("%d. Diagnostic counts do not match: exp=%d got=%d\n%v", i, len(tt.diags), len(ds), ds)
+			continue
+		}
+		for j, d := range tt.diags {
+			if ds[j].Severity != d.sev || ds[j].Code != d.code {
+				t.Errorf("%d. %d. Diagnostic mismatch: exp=%s %s got=%s %s", i, j, d.sev, d.code, ds[j].Severity, ds[j].Code)
+			}
+			if exp := (Position{File: "test.dart", Line: 1, Col: 1}); ds[j].Pos != exp {
+				t.Errorf("%d. %d. Position mismatch: exp=%v got=%v", i, j, exp, ds[j].Pos)
+			}
+		}
+	}
+}
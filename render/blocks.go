@@ -0,0 +1,69 @@
+package render
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/butlermatt/dsdoc/parser"
+)
+
+// textBlocks returns a Long description as plain text. List items are
+// prefixed by a bullet or number and code is indented by four spaces.
+func textBlocks(bs parser.Blocks) string {
+	var parts []string
+	for _, b := range bs {
+		switch b.Kind {
+		case parser.ListBlock:
+			var items []string
+			for i, it := range b.Items {
+				if b.Start == 0 {
+					items = append(items, "  - "+it)
+				} else {
+					items = append(items, fmt.Sprintf("  %d. %s", b.Start+i, it))
+				}
+			}
+			parts = append(parts, strings.Join(items, "\n"))
+		case parser.CodeBlock:
+			parts = append(parts, indent(4, b.Text))
+		default:
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// htmlBlocks returns a Long description as HTML paragraphs, lists and
+// preformatted code.
+func htmlBlocks(bs parser.Blocks) template.HTML {
+	var buf strings.Builder
+	for _, b := range bs {
+		switch b.Kind {
+		case parser.ListBlock:
+			if b.Start == 0 {
+				buf.WriteString("<ul>\n")
+			} else if b.Start == 1 {
+				buf.WriteString("<ol>\n")
+			} else {
+				fmt.Fprintf(&buf, "<ol start=\"%d\">\n", b.Start)
+			}
+			for _, it := range b.Items {
+				fmt.Fprintf(&buf, "<li>%s</li>\n", template.HTMLEscapeString(it))
+			}
+			if b.Start == 0 {
+				buf.WriteString("</ul>\n")
+			} else {
+				buf.WriteString("</ol>\n")
+			}
+		case parser.CodeBlock:
+			buf.WriteString("<pre><code")
+			if b.Lang != "" {
+				fmt.Fprintf(&buf, " class=\"language-%s\"", template.HTMLEscapeString(b.Lang))
+			}
+			fmt.Fprintf(&buf, ">%s</code></pre>\n", template.HTMLEscapeString(b.Text))
+		default:
+			fmt.Fprintf(&buf, "<p>%s</p>\n", template.HTMLEscapeString(b.Text))
+		}
+	}
+	return template.HTML(strings.TrimSuffix(buf.String(), "\n"))
+}
@@ -0,0 +1,29 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/butlermatt/dsdoc/parser"
+)
+
+func TestBlocks(t *testing.T) {
+	bs := parser.Blocks{
+		{Text: "Retries <forever>."},
+		{Kind: parser.ListBlock, Items: []string{"tcp", "tls"}},
+		{Kind: parser.ListBlock, Items: []string{"open", "close"}, Start: 3},
+		{Kind: parser.CodeBlock, Lang: "go", Text: "if a < b {\n\tclose()\n}"},
+	}
+
+	expText := "Retries <forever>.\n\n  - tcp\n  - tls\n\n  3. open\n  4. close\n\n    if a < b {\n    \tclose()\n    }"
+	if got := textBlocks(bs); got != expText {
+		t.Errorf("Text mismatch:\nexp=%q\ngot=%q", expText, got)
+	}
+
+	expHTML := "<p>Retries &lt;forever&gt;.</p>\n" +
+		"<ul>\n<li>tcp</li>\n<li>tls</li>\n</ul>\n" +
+		"<ol start=\"3\">\n<li>open</li>\n<li>close</li>\n</ol>\n" +
+		"<pre><code class=\"language-go\">if a &lt; b {\n\tclose()\n}</code></pre>"
+	if got := string(htmlBlocks(bs)); got != expHTML {
+		t.Errorf("HTML mismatch:\nexp=%q\ngot=%q", expHTML, got)
+	}
+}
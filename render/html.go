@@ -0,0 +1,268 @@
+package render
+
+import (
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/butlermatt/dsdoc/parser"
+)
+
+// htmlEntry is a document in the generated HTML page.
+type htmlEntry struct {
+	Doc      *parser.Document
+	Children []*htmlEntry
+}
+
+// htmlIndex is an entry of the client side search index.
+type htmlIndex struct {
+	Name   string `json:"n"`
+	Anchor string `json:"a"`
+	Type   string `json:"t"`
+	Short  string `json:"s"`
+	Is     string `json:"i,omitempty"`
+}
+
+type htmlPage struct {
+	Title    string
+	Link     *parser.Document
+	Tree     []*htmlEntry
+	Sections []*htmlEntry
+	Index    []htmlIndex
+}
+
+var htmlFuncs = template.FuncMap{
+	"anchor":  htmlAnchor,
+	"blocks":  htmlBlocks,
+	"hasmeta": hasMeta,
+	"lower":   strings.ToLower,
+}
+
+var htmlTmpl = template.Must(template.New("page").Funcs(htmlFuncs).Parse(htmlPageTmpl))
+
+func init() {
+	Register("html", ".html", HTML{})
+}
+
+// HTML renders the document tree as a single, self-contained HTML page with
+// all styles and scripts inlined.
+type HTML struct{}
+
+// Render implements Renderer. The sidebar and sections are built from the
+// same walk of the tree as the markdown output.
+func (HTML) Render(w io.Writer, doc *parser.Document, opts Options) error {
+	page := &htmlPage{Title: doc.Name}
+	if doc.Type == parser.LinkDoc {
+		page.Link = doc
+	}
+
+	// stack holds the most recent entry at each depth, so that each entry
+	// can be added to the children of its parent.
+	var stack []*htmlEntry
+	walk(doc, 0, opts, func(d *parser.Document, depth int) {
+		e := &htmlEntry{Doc: d}
+		stack = append(stack[:depth], e)
+		if depth == 0 {
+			page.Tree = append(page.Tree, e)
+		} else {
+			p := stack[depth-1]
+			p.Children = append(p.Children, e)
+		}
+
+		if d.Type == parser.LinkDoc {
+			return
+		}
+		page.Sections = append(page.Sections, e)
+		page.Index = append(page.Index, htmlIndex{
+			Name:   d.Name,
+			Anchor: htmlAnchor(d),
+			Type:   d.Type.String(),
+			Short:  d.Short,
+			Is:     d.Is,
+		})
+	})
+
+	return htmlTmpl.Execute(w, page)
+}
+
+// htmlAnchor returns the element id of the section for d. MetaNames are
+// unique, unlike names, so they are used for the id.
+func htmlAnchor(d *parser.Document) string {
+	if d.MetaName == "" {
+		return strings.ToLower(d.Name)
+	}
+	return d.MetaName
+}
+
+const htmlPageTmpl = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} API</title>
+<style>
+* { box-sizing: border-box; }
+body { margin: 0; font: 15px/1.5 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #24292e; }
+nav { position: fixed; top: 0; bottom: 0; left: 0; width: 300px; overflow: auto; padding: 1em; background: #f6f8fa; border-right: 1px solid #e1e4e8; }
+main { margin-left: 300px; padding: 1em 2em; max-width: 1000px; }
+nav ul { list-style: none; margin: 0; padding-left: 1em; }
+nav > div > ul { padding-left: 0; }
+nav a { color: #0366d6; text-decoration: none; }
+nav a:hover { text-decoration: underline; }
+nav a.action::before { content: "@"; }
+summary { cursor: pointer; }
+#search { width: 100%; padding: .4em; margin-bottom: 1em; border: 1px solid #d1d5da; border-radius: 3px; }
+#results li { margin-bottom: .5em; }
+#results small { display: block; color: #586069; }
+section { border-top: 1px solid #e1e4e8; padding: 1em 0; }
+h1, h2 { margin: .2em 0; }
+h2 .badge { font-size: 12px; vertical-align: middle; }
+.badge { display: inline-block; padding: 0 .5em; border-radius: 1em; font-size: 12px; font-weight: 600; background: #e1e4e8; }
+.badge.node { background: #dbedff; }
+.badge.action { background: #ffe5b4; }
+.badge.type { background: #e6ffed; font-family: monospace; }
+.badge.never { background: #f1f8ff; }
+.badge.write { background: #fff5b1; }
+.badge.config { background: #ffdce0; }
+.badge.read { background: #e6ffed; }
+.badge.mode { background: #f5f0ff; }
+.short { font-size: 1.1em; }
+dl { display: grid; grid-template-columns: max-content auto; gap: .2em 1em; }
+dt { font-weight: 600; }
+dd { margin: 0; }
+table { border-collapse: collapse; margin: .5em 0 1em; }
+th, td { border: 1px solid #dfe2e5; padding: .3em .8em; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+ul.options { margin: .2em 0; padding-left: 1.2em; }
+</style>
+</head>
+<body>
+<nav>
+<input id="search" type="search" placeholder="Search..." autocomplete="off">
+<ul id="results" hidden></ul>
+<div id="tree">{{template "tree" .Tree}}</div>
+</nav>
+<main>
+{{- with .Link}}
+<header id="{{anchor .}}">
+<h1>{{.Name}}</h1>
+{{- if .Short}}
+<p class="short">{{.Short}}</p>
+{{- end}}
+{{- if .Long}}
+{{blocks .Long}}
+{{- end}}
+<dl>
+{{- if .Version}}<dt>Version</dt><dd><code>{{.Version}}</code></dd>{{end}}
+{{- if .Author}}<dt>Author</dt><dd>{{.Author}}</dd>{{end}}
+{{- if .License}}<dt>License</dt><dd>{{.License}}</dd>{{end}}
+{{- if .Homepage}}<dt>Homepage</dt><dd><a href="{{.Homepage}}">{{.Homepage}}</a></dd>{{end}}
+</dl>
+</header>
+{{- end}}
+{{range .Sections}}{{with .Doc}}
+<section id="{{anchor .}}">
+<h2>{{.Name}} <span class="badge {{lower .Type.String}}">{{.Type}}</span></h2>
+<p class="short">{{.Short}}</p>
+<dl>
+{{- if .Is}}<dt>$is</dt><dd><code>{{.Is}}</code></dd>{{end}}
+{{- with .Parent}}<dt>Parent</dt><dd><a href="#{{anchor .}}">{{.Name}}</a></dd>{{end}}
+{{- if .Return}}<dt>Return type</dt><dd><span class="badge type">{{.Return}}</span>{{with .ReturnMode}} <span class="badge mode">{{.}}</span>{{end}}</dd>{{end}}
+{{- if .Invokable}}<dt>Invokable</dt><dd><span class="badge {{.Invokable}}">{{.Invokable}}</span></dd>{{end}}
+{{- if .ValueType.Kind}}<dt>Value type</dt><dd>{{template "type" .ValueType}}</dd>
+<dt>Writable</dt><dd><span class="badge {{.Writable}}">{{.Writable}}</span></dd>{{end}}
+</dl>
+{{- if .Long}}
+{{blocks .Long}}
+{{- end}}
+{{- if .Params}}
+<h3>Params</h3>
+{{template "params" .Params}}
+{{- end}}
+{{- if .Columns}}
+<h3>Columns</h3>
+{{template "params" .Columns}}
+{{- end}}
+{{- if .Attributes}}
+<h3>Attributes</h3>
+{{template "params" .Attributes}}
+{{- end}}
+{{- if .Configs}}
+<h3>Configs</h3>
+{{template "params" .Configs}}
+{{- end}}
+{{- if .Examples}}
+<h3>Examples</h3>
+{{- range .Examples}}
+{{- if .Title}}
+<h4>{{.Title}}</h4>
+{{- end}}
+{{- if .Params}}
+<pre><code>{{range $i, $p := .Params}}{{if $i}}
+{{end}}{{$p.Name}}: {{$p.Value}}{{end}}</code></pre>
+{{- end}}
+{{- if .Columns}}
+<table>
+<tr>{{range .Columns}}<th>{{.}}</th>{{end}}</tr>
+{{- range .Rows}}
+<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+{{- end}}
+</section>
+{{- end}}{{end}}
+</main>
+<script>
+(function() {
+  var index = {{.Index}};
+  var search = document.getElementById("search");
+  var results = document.getElementById("results");
+  var tree = document.getElementById("tree");
+  search.addEventListener("input", function() {
+    var q = search.value.trim().toLowerCase();
+    results.innerHTML = "";
+    results.hidden = q === "";
+    tree.hidden = q !== "";
+    if (q === "") {
+      return;
+    }
+    index.forEach(function(e) {
+      var text = (e.n + " " + e.s + " " + (e.i || "")).toLowerCase();
+      if (text.indexOf(q) === -1) {
+        return;
+      }
+      var li = document.createElement("li");
+      var a = document.createElement("a");
+      a.href = "#" + e.a;
+      a.textContent = e.n;
+      a.className = e.t.toLowerCase();
+      var small = document.createElement("small");
+      small.textContent = e.s;
+      li.appendChild(a);
+      li.appendChild(small);
+      results.appendChild(li);
+    });
+  });
+})();
+</script>
+</body>
+</html>
+{{define "tree"}}<ul>
+{{- range .}}
+<li>{{if .Children}}<details open><summary>{{template "entry" .Doc}}</summary>{{template "tree" .Children}}</details>{{else}}{{template "entry" .Doc}}{{end}}</li>
+{{- end}}
+</ul>{{end}}
+{{define "entry"}}<a href="#{{anchor .}}" class="{{lower .Type.String}}">{{.Name}}</a>{{end}}
+{{define "type"}}<span class="badge type">{{.Kind}}</span>
+{{- if .Options}}<ul class="options">{{range .Options}}<li><code>{{.}}</code></li>{{end}}</ul>{{end}}{{end}}
+{{define "params"}}{{$meta := hasmeta .}}<table>
+<tr><th>Name</th><th>Type</th><th>Description</th>
+{{- if $meta}}<th>Default</th><th>Placeholder</th><th>Required</th><th>Editor</th>{{end}}</tr>
+{{- range .}}
+<tr><td><code>{{.Name}}</code></td><td>{{template "type" .Type}}</td><td>{{.Description}}</td>
+{{- if $meta}}<td>{{with .Default}}<code>{{.}}</code>{{end}}</td><td>{{.Placeholder}}</td><td>{{if .Required}}Yes{{end}}</td><td>{{.Editor}}</td>{{end}}</tr>
+{{- end}}
+</table>{{end}}
+`
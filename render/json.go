@@ -0,0 +1,20 @@
+package render
+
+import (
+	"io"
+
+	"github.com/butlermatt/dsdoc/parser"
+)
+
+func init() {
+	Register("json", ".json", JSON{})
+}
+
+// JSON renders the document tree in the versioned JSON schema written by
+// parser.WriteJSON. Children are always listed in source order.
+type JSON struct{}
+
+// Render implements Renderer.
+func (JSON) Render(w io.Writer, doc *parser.Document, opts Options) error {
+	return parser.WriteJSON(w, doc)
+}
@@ -0,0 +1,73 @@
+// Package render generates documentation from a parsed document tree. Each
+// output format is provided by a Renderer registered under the name used to
+// select it on the command line.
+package render
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/butlermatt/dsdoc/parser"
+)
+
+// Options controls how a document tree is rendered.
+type Options struct {
+	// Sort is the order in which the children of each document are listed.
+	Sort parser.SortOrder
+}
+
+// Renderer renders a document tree in a specific output format.
+type Renderer interface {
+	Render(w io.Writer, root *parser.Document, opts Options) error
+}
+
+// Format is a registered output format.
+type Format struct {
+	// Name is the name the format is selected by, eg md.
+	Name string
+	// Ext is the file extension of the output, including the leading dot.
+	Ext string
+	Renderer
+}
+
+var formats = make(map[string]*Format)
+
+// Register makes a Renderer available under name. The ext is used to name
+// output files when several formats are generated at once. Register panics
+// if name is already registered.
+func Register(name, ext string, r Renderer) {
+	if _, ok := formats[name]; ok {
+		panic(fmt.Sprintf("render: format %q registered twice", name))
+	}
+	formats[name] = &Format{Name: name, Ext: ext, Renderer: r}
+}
+
+// Lookup returns the Format registered under name.
+func Lookup(name string) (*Format, error) {
+	f, ok := formats[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown output type %q, expected one of %s", name, strings.Join(Names(), ", "))
+	}
+	return f, nil
+}
+
+// Names returns the names of the registered formats, sorted.
+func Names() []string {
+	var ns []string
+	for n := range formats {
+		ns = append(ns, n)
+	}
+	sort.Strings(ns)
+	return ns
+}
+
+// walk calls fn for doc and then each of its descendants, depth first,
+// listing children in the order given by opts.
+func walk(doc *parser.Document, depth int, opts Options, fn func(doc *parser.Document, depth int)) {
+	fn(doc, depth)
+	for _, ch := range doc.SortedChildren(opts.Sort) {
+		walk(ch, depth+1, opts, fn)
+	}
+}
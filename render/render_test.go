@@ -0,0 +1,117 @@
+package render
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/butlermatt/dsdoc/parser"
+	"github.com/butlermatt/dsdoc/trim"
+)
+
+// parseExample parses testdata/example.dart, which holds the examples from
+// the README.
+func parseExample(t *testing.T) *parser.Document {
+	data, err := ioutil.ReadFile("testdata/example.dart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := parser.NewParser()
+	for _, b := range trim.TrimDsDoc(strings.Split(string(data), "\n"), "example.dart") {
+		if err := p.Parse(b); err != nil {
+			t.Fatalf("Unexpected parse error %q", err)
+		}
+	}
+	doc, err := p.Build()
+	if err != nil {
+		t.Fatalf("Unexpected build error %q", err)
+	}
+	return doc
+}
+
+func TestRender(t *testing.T) {
+	var tests = []struct {
+		format string
+		golden string
+	}{
+		{format: "md", golden: "testdata/example.md"},
+		{format: "text", golden: "testdata/example.txt"},
+		{format: "html", golden: "testdata/example.html"},
+	}
+
+	doc := parseExample(t)
+	opts := Options{Sort: parser.ActionsFirst}
+	for _, tt := range tests {
+		exp, err := ioutil.ReadFile(tt.golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := Lookup(tt.format)
+		if err != nil {
+			t.Fatalf("%s. Unexpected lookup error %q", tt.format, err)
+		}
+
+		// Render twice to ensure no output is retained between renders.
+		for i := 0; i < 2; i++ {
+			var b bytes.Buffer
+			if err := f.Render(&b, doc, opts); err != nil {
+				t.Fatalf("%s. Unexpected render error %q", tt.format, err)
+			}
+			if b.String() != string(exp) {
+				t.Errorf("%s. %d. Output does not match %s:\n%s", tt.format, i, tt.golden, b.String())
+			}
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	exp := []string{"html", "json", "md", "text"}
+	names := Names()
+	if strings.Join(names, ",") != strings.Join(exp, ",") {
+		t.Errorf("Format names mismatch: exp=%v got=%v", exp, names)
+	}
+	for _, n := range exp {
+		f, err := Lookup(n)
+		if err != nil {
+			t.Errorf("%s. Unexpected error %q", n, err)
+		} else if f.Name != n || !strings.HasPrefix(f.Ext, ".") {
+			t.Errorf("%s. Format mismatch: got name=%q ext=%q", n, f.Name, f.Ext)
+		}
+	}
+	if _, err := Lookup("pdf"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+// Ensure the HTML page loads no external assets, and links only to its own
+// sections.
+func TestHTML_SelfContained(t *testing.T) {
+	var b bytes.Buffer
+	if err := (HTML{}).Render(&b, parseExample(t), Options{}); err != nil {
+		t.Fatalf("Unexpected render error %q", err)
+	}
+	out := b.String()
+	if strings.Contains(out, " src=") || strings.Contains(out, "<link") {
+		t.Errorf("Output loads an external asset:\n%s", out)
+	}
+	for _, s := range strings.Split(out, ` href="`)[1:] {
+		if !strings.HasPrefix(s, "#") {
+			t.Errorf("Unexpected external link %q", s[:strings.Index(s, `"`)])
+		}
+	}
+}
+
+func TestRender_Formats(t *testing.T) {
+	doc := parseExample(t)
+	for _, n := range Names() {
+		f, _ := Lookup(n)
+		var b bytes.Buffer
+		if err := f.Render(&b, doc, Options{}); err != nil {
+			t.Errorf("%s. Unexpected render error %q", n, err)
+		}
+		if !strings.Contains(b.String(), "Remove_Device") {
+			t.Errorf("%s. Output is missing documents:\n%s", n, b.String())
+		}
+	}
+}
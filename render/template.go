@@ -0,0 +1,293 @@
+package render
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/butlermatt/dsdoc/parser"
+	"github.com/butlermatt/dsdoc/types"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// defaultTemplates maps the name of the built in formats to the embedded
+// template which implements them.
+var defaultTemplates = map[string]string{
+	"md":   "templates/markdown.tmpl",
+	"text": "templates/text.tmpl",
+}
+
+func init() {
+	for name, file := range defaultTemplates {
+		src, err := templateFS.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		t, err := NewTemplate(filepath.Base(file), string(src), false)
+		if err != nil {
+			panic(err)
+		}
+		ext := ".md"
+		if name == "text" {
+			ext = ".txt"
+		}
+		Register(name, ext, t)
+	}
+}
+
+// DefaultTemplate returns the source of the template used by the built in
+// format name, so that it may be copied and customised.
+func DefaultTemplate(name string) (string, error) {
+	file, ok := defaultTemplates[name]
+	if !ok {
+		var ns []string
+		for n := range defaultTemplates {
+			ns = append(ns, n)
+		}
+		sort.Strings(ns)
+		return "", fmt.Errorf("no template for format %q, expected one of %s", name, strings.Join(ns, ", "))
+	}
+	src, err := templateFS.ReadFile(file)
+	return string(src), err
+}
+
+// TemplateData is the value a template is executed with.
+type TemplateData struct {
+	// Root is the root of the document tree.
+	Root *TemplateDoc
+	// Docs lists every document in the tree, depth first.
+	Docs []*TemplateDoc
+}
+
+// TemplateDoc is a document as seen by a template.
+type TemplateDoc struct {
+	*parser.Document
+	// Depth is the depth of the document in the tree, the root is 0.
+	Depth int
+	// Children holds the children of the document in the selected order.
+	Children []*TemplateDoc
+}
+
+// executor is implemented by both text/template and html/template.
+type executor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// Template renders the document tree by executing a text/template or an
+// html/template with TemplateData. The functions listed in TemplateFuncs are
+// available to the template.
+type Template struct {
+	t executor
+}
+
+// TemplateFuncs are the helper functions available to templates.
+var TemplateFuncs = map[string]interface{}{
+	// tree returns the hierarchy prefix for a document at depth.
+	"tree": func(depth int) string { return strings.Repeat(" |", depth) },
+	// anchor returns the markdown heading anchor for name.
+	"anchor": strings.ToLower,
+	// indent prefixes every line of s with n spaces.
+	"indent": indent,
+	// mdescape escapes characters which have a meaning in markdown.
+	"mdescape": mdEscape,
+	// mdtype returns a type for a markdown table cell.
+	"mdtype": mdCellType,
+	// mdtable returns a markdown table of columns and rows.
+	"mdtable": mdTable,
+	// texttable returns the columns and rows of a table aligned as plain
+	// text, prefixed by prefix.
+	"texttable": textTable,
+	// texttype returns the Type line of a text section, prefixed by prefix.
+	"texttype": textType,
+	// textblocks returns a Long description as plain text.
+	"textblocks": textBlocks,
+	// htmlblocks returns a Long description as HTML.
+	"htmlblocks": htmlBlocks,
+	// hasmeta reports whether any of the parameters has a @Default,
+	// @Placeholder, @Required or @Editor.
+	"hasmeta": hasMeta,
+	// params returns the comma separated parameter names of an Action.
+	"params": paramNames,
+	"isLink": func(d *TemplateDoc) bool { return d.Type == parser.LinkDoc },
+	"isNode": func(d *TemplateDoc) bool { return d.Type == parser.NodeDoc },
+	"isAction": func(d *TemplateDoc) bool {
+		return d.Type == parser.ActionDoc
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"join":  strings.Join,
+}
+
+// NewTemplate parses src as a template named name. If html is true it is
+// parsed with html/template, which escapes its output.
+func NewTemplate(name, src string, html bool) (*Template, error) {
+	var (
+		t   executor
+		err error
+	)
+	if html {
+		t, err = htmltemplate.New(name).Funcs(htmltemplate.FuncMap(TemplateFuncs)).Parse(src)
+	} else {
+		t, err = template.New(name).Funcs(template.FuncMap(TemplateFuncs)).Parse(src)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Template{t: t}, nil
+}
+
+// LoadTemplate reads and parses the template at path. Files with an html,
+// htm or gohtml extension, optionally followed by .tmpl, are parsed with
+// html/template.
+func LoadTemplate(path string) (*Template, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch TemplateExt(path) {
+	case ".html", ".htm", ".gohtml":
+		return NewTemplate(filepath.Base(path), string(src), true)
+	}
+	return NewTemplate(filepath.Base(path), string(src), false)
+}
+
+// TemplateExt returns the extension of the output of the template at path,
+// ignoring a trailing .tmpl, eg wiki.md.tmpl returns .md.
+func TemplateExt(path string) string {
+	return filepath.Ext(strings.TrimSuffix(path, ".tmpl"))
+}
+
+// Render implements Renderer.
+func (t *Template) Render(w io.Writer, doc *parser.Document, opts Options) error {
+	data := &TemplateData{}
+	var stack []*TemplateDoc
+	walk(doc, 0, opts, func(d *parser.Document, depth int) {
+		td := &TemplateDoc{Document: d, Depth: depth}
+		stack = append(stack[:depth], td)
+		if depth == 0 {
+			data.Root = td
+		} else {
+			p := stack[depth-1]
+			p.Children = append(p.Children, td)
+		}
+		data.Docs = append(data.Docs, td)
+	})
+	return t.t.Execute(w, data)
+}
+
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = pad + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+var mdReplacer = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `{`, `\{`, `}`, `\}`,
+	`[`, `\[`, `]`, `\]`, `<`, `\<`, `>`, `\>`, `#`, `\#`, `|`, `\|`,
+)
+
+func mdEscape(s string) string { return mdReplacer.Replace(s) }
+
+func paramNames(d *TemplateDoc) string {
+	var ns []string
+	for _, p := range d.Params {
+		ns = append(ns, p.Name)
+	}
+	return strings.Join(ns, ", ")
+}
+
+func hasMeta(ps []*parser.Parameter) bool {
+	for _, p := range ps {
+		if p.Default != "" || p.Placeholder != "" || p.Required || p.Editor != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// mdCellType returns a type for use in a markdown table cell, with any enum
+// options as a list.
+func mdCellType(t types.Type) string {
+	s := fmt.Sprintf("`%s`", t.Kind)
+	if len(t.Options) > 0 {
+		s += "<ul>"
+		for _, o := range t.Options {
+			s += fmt.Sprintf("<li>`%s`</li>", o)
+		}
+		s += "</ul>"
+	}
+	return s
+}
+
+// textType returns the Type line of a text section with any enum options
+// listed beneath it.
+func textType(prefix string, t types.Type) string {
+	s := fmt.Sprintln(prefix+"Type:", t.Kind)
+	for _, o := range t.Options {
+		s += fmt.Sprintln(prefix+"  -", o)
+	}
+	return s
+}
+
+var mdCellReplacer = strings.NewReplacer(`|`, `\|`, "\n", " ")
+
+// mdTable returns a markdown table with a header of columns and rows. Pipes
+// within cells are escaped.
+func mdTable(columns []string, rows [][]string) string {
+	var b strings.Builder
+	row := func(cells []string) {
+		b.WriteString("|")
+		for _, c := range cells {
+			b.WriteString(" " + mdCellReplacer.Replace(c) + " |")
+		}
+		b.WriteString("\n")
+	}
+	row(columns)
+	b.WriteString(strings.Repeat("| --- ", len(columns)) + "|\n")
+	for _, r := range rows {
+		row(r)
+	}
+	return b.String()
+}
+
+// textTable returns a table with a header of columns and rows, each line
+// prefixed by prefix and the cells padded to the width of their column.
+func textTable(prefix string, columns []string, rows [][]string) string {
+	widths := make([]int, len(columns))
+	for _, r := range append([][]string{columns}, rows...) {
+		for i, c := range r {
+			if i < len(widths) && utf8.RuneCountInString(c) > widths[i] {
+				widths[i] = utf8.RuneCountInString(c)
+			}
+		}
+	}
+	var b strings.Builder
+	for _, r := range append([][]string{columns}, rows...) {
+		line := prefix
+		for i, c := range r {
+			if i > 0 {
+				line += "  "
+			}
+			if i < len(widths) {
+				c += strings.Repeat(" ", widths[i]-utf8.RuneCountInString(c))
+			}
+			line += c
+		}
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	return b.String()
+}
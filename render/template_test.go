@@ -0,0 +1,89 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/butlermatt/dsdoc/parser"
+)
+
+func TestTemplate(t *testing.T) {
+	var tests = []struct {
+		src  string
+		html bool
+		exp  string
+	}{
+		{
+			src: "{{range .Docs}}{{tree .Depth}}{{.Name}}\n{{end}}",
+			exp: "root\n |Add_Device\n |DeviceNode\n | |Remove_Device\n | |version\n | | |versionNumber\n | | |releaseDate\n",
+		},
+		{
+			src: "{{range .Root.Children}}{{if isAction .}}{{.Name}}({{params .}}) {{end}}{{end}}",
+			exp: "Add_Device(url, name) ",
+		},
+		{src: "{{mdescape \"a_b*c\"}}", exp: `a\_b\*c`},
+		{src: "{{indent 2 \"a\\n\\nb\"}}", exp: "  a\n\n  b"},
+		{src: "{{anchor .Root.Name}}", exp: "root"},
+		{src: "{{range .Root.Children}}{{if isAction .}}{{hasmeta .Params}}{{end}}{{end}}", exp: "false"},
+		{src: "{{\"<b>\"}}", exp: "<b>"},
+		{src: "{{\"<b>\"}}", html: true, exp: "&lt;b&gt;"},
+	}
+
+	doc := parseExample(t)
+	for i, tt := range tests {
+		tmpl, err := NewTemplate("test", tt.src, tt.html)
+		if err != nil {
+			t.Fatalf("%d. Unexpected parse error %q", i, err)
+		}
+		var b bytes.Buffer
+		if err := tmpl.Render(&b, doc, Options{Sort: parser.ActionsFirst}); err != nil {
+			t.Fatalf("%d. Unexpected render error %q", i, err)
+		}
+		if b.String() != tt.exp {
+			t.Errorf("%d. Output mismatch:\nexp=%q\ngot=%q", i, tt.exp, b.String())
+		}
+	}
+}
+
+func TestTemplateExt(t *testing.T) {
+	var tests = map[string]string{
+		"wiki.md.tmpl": ".md",
+		"page.html":    ".html",
+		"out.tmpl":     "",
+	}
+	for in, exp := range tests {
+		if got := TemplateExt(in); got != exp {
+			t.Errorf("%s. Extension mismatch: exp=%q got=%q", in, exp, got)
+		}
+	}
+}
+
+func TestDefaultTemplate(t *testing.T) {
+	for _, n := range []string{"md", "text"} {
+		if src, err := DefaultTemplate(n); err != nil || src == "" {
+			t.Errorf("%s. Expected template source, got err=%v", n, err)
+		}
+	}
+	if _, err := DefaultTemplate("html"); err == nil {
+		t.Error("Expected an error for a format without a template")
+	}
+}
+
+func TestTables(t *testing.T) {
+	cols := []string{"name", "value"}
+	rows := [][]string{{"a", "x|y"}, {"longer", "1"}}
+
+	expMD := "| name | value |\n| --- | --- |\n| a | x\\|y |\n| longer | 1 |\n"
+	if got := mdTable(cols, rows); got != expMD {
+		t.Errorf("Markdown mismatch:\nexp=%q\ngot=%q", expMD, got)
+	}
+	// A single column is only a table with its pipes.
+	expMD = "| ok |\n| --- |\n| true |\n"
+	if got := mdTable([]string{"ok"}, [][]string{{"true"}}); got != expMD {
+		t.Errorf("Markdown mismatch:\nexp=%q\ngot=%q", expMD, got)
+	}
+	expText := "  name    value\n  a       x|y\n  longer  1\n"
+	if got := textTable("  ", cols, rows); got != expText {
+		t.Errorf("Text mismatch:\nexp=%q\ngot=%q", expText, got)
+	}
+}
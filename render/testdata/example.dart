@@ -0,0 +1,64 @@
+//* @Action Add_Device
+//* @Is addDeviceCmd
+//* @Parent root
+//*
+//* Adds a device to the link.
+//*
+//* Add Device accepts the URL and name of the device to add to the link. It
+//* will verify the URL is accessible and return an error message if it fails.
+//*
+//* @Param url string The URL of the device.
+//* @Param name string The name for the device, it will be added to the link
+//* under this name.
+//*
+//* @Return values
+//* @Column success bool A boolean which represents if the action succeeded or
+//* not. Returns false on failure and true on success.
+//* @Column message string If the action succeeds, this will be "Success!", on
+//* failure, it will return the error message.
+
+
+//* @Node
+//* @MetaType DeviceNode
+//* @Is deviceNode
+//* @Parent root
+//*
+//* A device which has been added to the link.
+//*
+//* When added to the link, a device will appear as the name provided. This
+//* node maintains the connection with the remote host.
+
+
+//* @Action Remove_Device
+//* @Is removeDeviceCmd
+//* @Parent DeviceNode
+//*
+//* Removes a device from the link.
+//*
+//* @Return values
+//* @Column success bool A boolean which represents if the action succeeded or
+//* not. Returns false on failure and true on success.
+//* @Column message string If the action succeeds, this will be "Success!", on
+//* failure, it will return the error message.
+
+
+//* @Node version
+//* @Parent DeviceNode
+//*
+//* A hierarchy node which holds version value nodes.
+
+
+//* @Node versionNumber
+//* @Parent version
+//*
+//* String which holds the full version number.
+//*
+//* @Value string
+
+
+//* @Node releaseDate
+//* @Parent version
+//*
+//* String which holds the release date of the current version.
+//*
+//* @Value string write
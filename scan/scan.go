@@ -0,0 +1,139 @@
+// Package scan reads and parses source files concurrently.
+package scan
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/butlermatt/dsdoc/cache"
+	"github.com/butlermatt/dsdoc/decl"
+	"github.com/butlermatt/dsdoc/parser"
+	"github.com/butlermatt/dsdoc/trim"
+)
+
+// File is a file to scan.
+type File struct {
+	// Path is the path the file is read from.
+	Path string
+	// Name is the name problems in the file are reported by.
+	Name string
+}
+
+// Scanner reads and parses files.
+type Scanner struct {
+	// Jobs is the number of files read and parsed at once. Values less than
+	// one are treated as one.
+	Jobs int
+	// Errors receives the errors reading files, which do not stop the scan.
+	// If nil they are written to os.Stderr.
+	Errors io.Writer
+	// Cache, if set, holds the batches of source files from previous scans.
+	Cache *cache.Cache
+	// Config is the path of the project configuration file, "" if none. Its
+	// link and settings are left to package config.
+	Config string
+
+	mu sync.Mutex
+}
+
+// Scan reads and parses files, and returns a fragment for each in the same
+// order, which may be merged to build the same tree for any number of Jobs.
+// Files which are neither source nor declaration files have an empty
+// fragment.
+func (s *Scanner) Scan(files []File) []*parser.Fragment {
+	frags := make([]*parser.Fragment, len(files))
+	jobs := s.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	if jobs > len(files) {
+		jobs = len(files)
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(jobs)
+	for i := 0; i < jobs; i++ {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				frags[i] = s.ScanFile(files[i])
+			}
+		}()
+	}
+	for i := range files {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	return frags
+}
+
+// Match reports whether the file at path is scanned, being either a source
+// file of a known language or a declaration file.
+func Match(path string) bool {
+	_, ok := trim.Lookup(path)
+	return ok || decl.Match(path)
+}
+
+// ScanFile reads and parses a single file.
+func (s *Scanner) ScanFile(f File) *parser.Fragment {
+	if !Match(f.Path) {
+		return &parser.Fragment{}
+	}
+	isDecl := decl.Match(f.Path)
+	syn, _ := trim.Lookup(f.Path)
+
+	if !isDecl && s.Cache != nil {
+		bs, err := s.Cache.Trim(f.Path, f.Name, syn)
+		if err != nil {
+			s.error(err)
+			return &parser.Fragment{}
+		}
+		return parser.ParseFragment(bs...)
+	}
+
+	data, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		s.error(err)
+		return &parser.Fragment{}
+	}
+	if isDecl {
+		if s.isConfig(f.Path) {
+			return decl.ReadConfig(f.Name, data)
+		}
+		return decl.Read(f.Name, data)
+	}
+
+	strs := strings.Split(string(data), "\n")
+	return parser.ParseFragment(trim.Trim(strs, f.Name, syn)...)
+}
+
+func (s *Scanner) error(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := s.Errors
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintln(w, err)
+}
+
+// isConfig reports whether path is the project configuration file.
+func (s *Scanner) isConfig(path string) bool {
+	if s.Config == "" {
+		return false
+	}
+	a, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	b, err := filepath.Abs(s.Config)
+	return err == nil && a == b
+}
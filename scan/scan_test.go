@@ -0,0 +1,186 @@
+package scan
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/butlermatt/dsdoc/parser"
+)
+
+// makeTree writes a synthetic link of n source files, each documenting a
+// node with an action, to a new temporary directory. It returns the files
+// and a function to remove the directory.
+func makeTree(tb testing.TB, n int) ([]File, func()) {
+	dir, err := ioutil.TempDir("", "scan")
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	var files []File
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("src/pkg%d/Node%d.java", i%20, i)
+		var b strings.Builder
+		b.WriteString("package example;\n\n")
+		fmt.Fprintf(&b, "//* @Node node%d\n//* @Parent root\n//*\n//* Node %d.\n//*\n//* @Value number\n", i, i)
+		fmt.Fprintf(&b, "public class Node%d {\n", i)
+		for j := 0; j < 100; j++ {
+			fmt.Fprintf(&b, "    private String field%d = \"value //* %d\"; // comment\n", j, j)
+		}
+		fmt.Fprintf(&b, "    /***\n     * @Action reset\n     * @MetaType reset%d\n     * @Parent node%d\n     *\n     * Resets node %d.\n     *\n", i, i, i)
+		b.WriteString("     * @Param force bool Skip the checks.\n     */\n    void reset(boolean force) {}\n}\n")
+
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(b.String()), 0644); err != nil {
+			tb.Fatal(err)
+		}
+		files = append(files, File{Path: p, Name: name})
+	}
+	return files, func() { os.RemoveAll(dir) }
+}
+
+// build merges frags and returns the tree as JSON.
+func build(t *testing.T, frags []*parser.Fragment) string {
+	p := parser.NewParser()
+	for _, f := range frags {
+		p.Merge(f)
+	}
+	doc, err := p.Build()
+	if err != nil {
+		t.Fatalf("Unexpected build error %q", err)
+	}
+	var b bytes.Buffer
+	if err := parser.WriteJSON(&b, doc); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+// Ensure the tree is the same for any number of jobs.
+func TestScan(t *testing.T) {
+	files, done := makeTree(t, 50)
+	defer done()
+
+	exp := build(t, (&Scanner{Jobs: 1}).Scan(files))
+	if !strings.Contains(exp, `"name": "node49"`) || !strings.Contains(exp, `"name": "reset"`) {
+		t.Fatalf("Tree is missing documents:\n%s", exp)
+	}
+	for _, jobs := range []int{0, 2, 8, 100} {
+		if got := build(t, (&Scanner{Jobs: jobs}).Scan(files)); got != exp {
+			t.Errorf("%d. Tree differs from a serial scan", jobs)
+		}
+	}
+}
+
+func TestScanFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, data string) File {
+		p := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return File{Path: p, Name: name}
+	}
+	var errs bytes.Buffer
+	s := &Scanner{Errors: &errs}
+
+	var tests = []struct {
+		f    File
+		docs int
+	}{
+		{f: write("a.dart", "//* @Node a\n//* @Parent root\n"), docs: 1},
+		{f: write("b.dsdoc", "@Node b\n@Parent root\n---\n@Node c\n@Parent b\n"), docs: 2},
+		{f: write("dsdoc.yaml", "nodes:\n  - path: d\n    parent: root\n"), docs: 1},
+		{f: write("notes.txt", "//* @Node e\n"), docs: 0},
+		{f: File{Path: filepath.Join(dir, "missing.go"), Name: "missing.go"}, docs: 0},
+	}
+	for _, tt := range tests {
+		frag := s.ScanFile(tt.f)
+		if len(frag.Docs) != tt.docs {
+			t.Errorf("%s. Expected %d docs, found %d", tt.f.Name, tt.docs, len(frag.Docs))
+		}
+		for _, d := range frag.Docs {
+			if d.Pos.File != tt.f.Name {
+				t.Errorf("%s. File mismatch: got=%q", tt.f.Name, d.Pos.File)
+			}
+		}
+	}
+	if !strings.Contains(errs.String(), "missing.go") {
+		t.Errorf("Expected a read error, got %q", errs.String())
+	}
+}
+
+// Ensure only the configuration file leaves its link to package config.
+func TestScanFile_Config(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := []byte("link:\n  name: Example\nnodes:\n  - path: d\n    parent: root\n")
+	var files []File
+	for _, name := range []string{"dsdoc.yaml", "dsdoc.json"} {
+		p := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(p, src, 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, File{Path: p, Name: name})
+	}
+
+	s := &Scanner{Config: filepath.Join(dir, ".", "dsdoc.yaml")}
+	if frag := s.ScanFile(files[0]); len(frag.Docs) != 1 {
+		t.Errorf("Expected the configuration link to be skipped, found %v", frag.Docs)
+	}
+	if frag := s.ScanFile(files[1]); len(frag.Docs) != 2 || frag.Docs[0].Type != parser.LinkDoc {
+		t.Errorf("Expected a link and a node, found %v", frag.Docs)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	var tests = []struct {
+		path string
+		exp  bool
+	}{
+		{"lib/device.dart", true},
+		{"Device.JAVA", true},
+		{"api.dsdoc", true},
+		{"dsdoc.yaml", true},
+		{"config/device.dsdoc.json", true},
+		{"api.md", false},
+		{"api.json", false},
+		{"notes.txt", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.path); got != tt.exp {
+			t.Errorf("%s. Match mismatch: exp=%v got=%v", tt.path, tt.exp, got)
+		}
+	}
+}
+
+func benchmarkScan(b *testing.B, jobs int) {
+	files, done := makeTree(b, 2000)
+	defer done()
+	s := &Scanner{Jobs: jobs}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Scan(files)
+	}
+}
+
+func BenchmarkScan_1(b *testing.B)  { benchmarkScan(b, 1) }
+func BenchmarkScan_4(b *testing.B)  { benchmarkScan(b, 4) }
+func BenchmarkScan_16(b *testing.B) { benchmarkScan(b, 16) }
@@ -0,0 +1,130 @@
+package trim
+
+import "strings"
+
+// Quote describes a string or character literal.
+type Quote struct {
+	// Open and Close delimit the literal.
+	Open, Close string
+	// Raw literals have no escape sequences.
+	Raw bool
+	// Multiline literals may span lines. Other literals end at the end of
+	// the line even if they are not closed.
+	Multiline bool
+}
+
+// marker is the kind of DsDoc comment found on a line.
+type marker int
+
+const (
+	noMarker marker = iota
+	lineMarker
+	blockMarker
+)
+
+// lexer tracks the literals and regular comments of a source file, so that
+// DsDoc markers within them are ignored.
+type lexer struct {
+	syn Syntax
+	// quote is the literal which is open at the end of the previous line.
+	quote *Quote
+	// comment is true if a regular block comment is open.
+	comment bool
+}
+
+// next returns the kind and 0-based column of the first DsDoc comment in
+// str, which is the next line of the file.
+func (l *lexer) next(str string) (marker, int) {
+	syn := l.syn
+	i := 0
+	for i < len(str) {
+		if l.comment {
+			j := strings.Index(str[i:], syn.CommentEnd)
+			if j == -1 {
+				return noMarker, -1
+			}
+			i += j + len(syn.CommentEnd)
+			l.comment = false
+			continue
+		}
+		if l.quote != nil {
+			j, ok := closeQuote(str[i:], l.quote)
+			if !ok {
+				if !l.quote.Multiline {
+					l.quote = nil
+				}
+				return noMarker, -1
+			}
+			i += j
+			l.quote = nil
+			continue
+		}
+
+		rest := str[i:]
+		switch {
+		case syn.Start != "" && strings.HasPrefix(rest, syn.Start) &&
+			// Ignore banners such as /********* and empty comments /***/.
+			strings.IndexAny(rest[len(syn.Start):]+" ", "*/") != 0:
+			return blockMarker, i
+		case syn.Line != "" && strings.HasPrefix(rest, syn.Line):
+			return lineMarker, i
+		case syn.CommentStart != "" && strings.HasPrefix(rest, syn.CommentStart):
+			l.comment = true
+			i += len(syn.CommentStart)
+			continue
+		case syn.Comment != "" && strings.HasPrefix(rest, syn.Comment):
+			return noMarker, -1
+		}
+		if q := syn.quoteAt(rest); q != nil {
+			l.quote = q
+			i += len(q.Open)
+			continue
+		}
+		i++
+	}
+	if l.quote != nil && !l.quote.Multiline {
+		l.quote = nil
+	}
+	return noMarker, -1
+}
+
+// quoteAt returns the literal which opens at the start of s, if any.
+func (s Syntax) quoteAt(str string) *Quote {
+	if s.Lifetimes && isLifetime(str) {
+		return nil
+	}
+	for i := range s.Quotes {
+		if strings.HasPrefix(str, s.Quotes[i].Open) {
+			return &s.Quotes[i]
+		}
+	}
+	return nil
+}
+
+// isLifetime reports whether s starts with a lifetime such as 'a or 'static,
+// rather than a character literal such as 'a'.
+func isLifetime(s string) bool {
+	if len(s) < 2 || s[0] != '\'' || !isIdentStart(s[1]) {
+		return false
+	}
+	return len(s) < 3 || s[2] != '\''
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// closeQuote returns the index just past the end of the literal q in s, and
+// false if it is not closed in s.
+func closeQuote(s string, q *Quote) (int, bool) {
+	for i := 0; i < len(s); i++ {
+		if !q.Raw && s[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(s[i:], q.Close) {
+			return i + len(q.Close), true
+		}
+	}
+	return 0, false
+}
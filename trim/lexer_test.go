@@ -0,0 +1,146 @@
+package trim
+
+import (
+	"strings"
+	"testing"
+)
+
+// Ensure markers within literals and regular comments are ignored.
+func TestTrimDsDoc_Literals(t *testing.T) {
+	var tests = []struct {
+		path string
+		in   []string
+		out  []string
+	}{
+		{
+			path: "lib/device.dart",
+			in: []string{
+				`var url = "http://*.example.com"; //* @Node url`,
+				`var s = 'it\'s //* not'; //* @Parent root`,
+				`var raw = r'C:\'; //* raw`,
+				`var m = '''`,
+				`//* inside a multiline string`,
+				`''';`,
+				`// a comment with //* in it`,
+				`/* a block comment //* with a marker`,
+				`   //* still in the comment */ //* after`,
+			},
+			out: []string{`@Node url|@Parent root|raw`, `after`},
+		},
+		{
+			path: "main.go",
+			in: []string{
+				"var s = `",
+				"//* raw string",
+				"` //* one",
+				`var r = '"' //* two`,
+				`var u = "unterminated`,
+				`//* three`,
+			},
+			out: []string{`one|two`, `three`},
+		},
+		{
+			path: "Device.java",
+			in: []string{
+				`String s = """`,
+				`  //* text block`,
+				`  """; //* one`,
+				`/*** @Node "a" */`,
+			},
+			out: []string{`one`, `@Node "a"`},
+		},
+		{
+			path: "device.rs",
+			in: []string{
+				`fn f<'a>(s: &'a str) //* one`,
+				`fn g(s: &'static str) -> char { 'x' } //* two`,
+				`let c = '"'; //* three`,
+				`let q = '\''; let s = "it's //* not`,
+				`//* still in the string"; //* four`,
+			},
+			out: []string{`one|two|three`, `four`},
+		},
+		{
+			path: "app.js",
+			in: []string{
+				"const t = `${a}",
+				"//* template",
+				"`; //* one",
+				`const re = "/***/"; //* two`,
+			},
+			out: []string{`one|two`},
+		},
+		{
+			path: "device.py",
+			in: []string{
+				`s = "#* not"  #* one`,
+				`d = """`,
+				`#* docstring`,
+				`"""`,
+				`# comment #* not`,
+				`#* two`,
+			},
+			out: []string{`one`, `two`},
+		},
+		{
+			path: "run.sh",
+			in: []string{
+				`echo 'a\' #* one`,
+			},
+			out: []string{`one`},
+		},
+		{
+			path: "device.lua",
+			in: []string{
+				`local s = [[`,
+				`--* long string`,
+				`]] --* one`,
+				`--[[ comment`,
+				`--* in comment ]]`,
+				`print("--*") --* two`,
+			},
+			out: []string{`one`, `two`},
+		},
+		{
+			path: "schema.sql",
+			in: []string{
+				`SELECT '--*', 'it''s --*' --* one`,
+				`/* --* */ --* two`,
+			},
+			out: []string{`one|two`},
+		},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, b := range TrimDsDoc(tt.in, tt.path) {
+			got = append(got, strings.Join(b.Lines, "|"))
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.out, "\n") {
+			t.Errorf("%s. Batches mismatch:\nexp=%q\ngot=%q", tt.path, tt.out, got)
+		}
+	}
+}
+
+// Ensure the lexer reports the column of the marker.
+func TestLexer_Next(t *testing.T) {
+	l := &lexer{syn: goSyntax}
+	var tests = []struct {
+		in  string
+		m   marker
+		col int
+	}{
+		{in: `x := "//*" //* a`, m: lineMarker, col: 11},
+		{in: `/*** a`, m: blockMarker, col: 0},
+		{in: `/* //* */ /*** b */`, m: blockMarker, col: 10},
+		{in: `// //*`, m: noMarker, col: -1},
+		{in: `/* open`, m: noMarker, col: -1},
+		{in: `//* closed */ //* c`, m: lineMarker, col: 14},
+	}
+	for i, tt := range tests {
+		m, col := l.next(tt.in)
+		if m != tt.m || col != tt.col {
+			t.Errorf("%d. Marker mismatch: exp=%d,%d got=%d,%d", i, tt.m, tt.col, m, col)
+		}
+	}
+}
@@ -1,38 +1,311 @@
 package trim
 
 import (
+	"path/filepath"
 	"strings"
 )
 
-// Prefix is the DsDoc comment style.
+// Prefix is the DsDoc line comment style of C like languages.
 const Prefix string = "//*"
-const lenPrefix = len(Prefix)
-
-func TrimDsDoc(s []string) [][]string {
-	var r [][]string
-	var b []string
-
-	var found bool
-	var str string
-	for _, str = range s {
-		if j := strings.Index(str, Prefix); j != -1 {
-			found = true
-			b = append(b, strings.TrimSpace(str[j + lenPrefix:]))
+
+// Syntax describes how DsDoc comments are written in a language.
+type Syntax struct {
+	// Line is the prefix of a DsDoc line comment, eg //*.
+	Line string
+	// Start and End delimit a DsDoc block comment, eg /*** and */. Both are
+	// empty if the language has no block comments. Lines within a block may
+	// be decorated with a leading *.
+	Start, End string
+	// Comment starts a regular line comment, eg //.
+	Comment string
+	// CommentStart and CommentEnd delimit a regular block comment.
+	CommentStart, CommentEnd string
+	// Quotes lists the string and character literals of the language. Where
+	// one literal opens with a prefix of another, the longer is listed first.
+	Quotes []Quote
+	// Lifetimes is true if a ' followed by an identifier with no closing '
+	// is a lifetime, as in Rust, rather than an unterminated literal.
+	Lifetimes bool
+	// Separator, if set, marks a file whose entire content is DsDoc syntax
+	// without a comment prefix. Batches are separated by lines equal to it.
+	Separator string
+}
+
+var (
+	dquote   = Quote{Open: `"`, Close: `"`}
+	squote   = Quote{Open: "'", Close: "'"}
+	rawDq    = Quote{Open: `"`, Close: `"`, Raw: true}
+	rawSq    = Quote{Open: "'", Close: "'", Raw: true}
+	tripleDq = Quote{Open: `"""`, Close: `"""`, Multiline: true}
+	tripleSq = Quote{Open: "'''", Close: "'''", Multiline: true}
+	backtick = Quote{Open: "`", Close: "`", Multiline: true}
+)
+
+// cStyle returns the syntax of a language with C style comments and the
+// literals quotes.
+func cStyle(quotes ...Quote) Syntax {
+	return Syntax{
+		Line: Prefix, Start: "/***", End: "*/",
+		Comment: "//", CommentStart: "/*", CommentEnd: "*/",
+		Quotes: quotes,
+	}
+}
+
+// rustStyle returns the syntax of Rust, whose strings may span lines and
+// whose lifetimes open like character literals.
+func rustStyle() Syntax {
+	s := cStyle(Quote{Open: `"`, Close: `"`, Multiline: true}, squote)
+	s.Lifetimes = true
+	return s
+}
+
+var (
+	cSyntax    = cStyle(dquote, squote)
+	goSyntax   = cStyle(dquote, squote, Quote{Open: "`", Close: "`", Raw: true, Multiline: true})
+	dartSyntax = cStyle(
+		Quote{Open: `r"""`, Close: `"""`, Raw: true, Multiline: true},
+		Quote{Open: "r'''", Close: "'''", Raw: true, Multiline: true},
+		tripleDq, tripleSq,
+		Quote{Open: `r"`, Close: `"`, Raw: true},
+		Quote{Open: "r'", Close: "'", Raw: true},
+		dquote, squote,
+	)
+	javaSyntax   = cStyle(tripleDq, dquote, squote)
+	kotlinSyntax = cStyle(Quote{Open: `"""`, Close: `"""`, Raw: true, Multiline: true}, dquote, squote)
+	csSyntax     = cStyle(Quote{Open: `@"`, Close: `"`, Raw: true, Multiline: true}, dquote, squote)
+	swiftSyntax  = cStyle(tripleDq, dquote)
+	rustSyntax   = rustStyle()
+	jsSyntax     = cStyle(dquote, squote, backtick)
+
+	pySyntax   = Syntax{Line: "#*", Comment: "#", Quotes: []Quote{tripleDq, tripleSq, dquote, squote}}
+	rbSyntax   = Syntax{Line: "#*", Comment: "#", Quotes: []Quote{dquote, squote}}
+	shSyntax   = Syntax{Line: "#*", Comment: "#", Quotes: []Quote{dquote, rawSq}}
+	yamlSyntax = Syntax{Line: "#*", Comment: "#", Quotes: []Quote{dquote, rawSq}}
+	luaSyntax  = Syntax{
+		Line: "--*", Comment: "--", CommentStart: "--[[", CommentEnd: "]]",
+		Quotes: []Quote{{Open: "[[", Close: "]]", Raw: true, Multiline: true}, dquote, squote},
+	}
+	sqlSyntax = Syntax{
+		Line: "--*", Comment: "--", CommentStart: "/*", CommentEnd: "*/",
+		Quotes: []Quote{rawDq, rawSq},
+	}
+)
+
+// Syntaxes maps a file extension, including the leading dot, to the DsDoc
+// comment syntax of the language.
+var Syntaxes = map[string]Syntax{
+	".dart":  dartSyntax,
+	".java":  javaSyntax,
+	".kt":    kotlinSyntax,
+	".go":    goSyntax,
+	".c":     cSyntax,
+	".h":     cSyntax,
+	".cpp":   cSyntax,
+	".cc":    cSyntax,
+	".hpp":   cSyntax,
+	".cs":    csSyntax,
+	".swift": swiftSyntax,
+	".rs":    rustSyntax,
+	".js":    jsSyntax,
+	".ts":    jsSyntax,
+	".es":    jsSyntax,
+	".py":    pySyntax,
+	".rb":    rbSyntax,
+	".sh":    shSyntax,
+	".bash":  shSyntax,
+	".yaml":  yamlSyntax,
+	".yml":   yamlSyntax,
+	".lua":   luaSyntax,
+	".sql":   sqlSyntax,
+	".dsdoc": {Separator: "---"},
+}
+
+// Lookup returns the comment syntax for the file at path, by its extension.
+func Lookup(path string) (Syntax, bool) {
+	s, ok := Syntaxes[strings.ToLower(filepath.Ext(path))]
+	return s, ok
+}
+
+// Batch is a contiguous block of DsDoc comment lines from a single file.
+type Batch struct {
+	// File is the path of the source file the batch was read from.
+	File string
+	// Line is the 1-based line number of the first line in the batch.
+	Line int
+	// Cols holds the 1-based column where the content of each line starts.
+	Cols []int
+	// Lines holds the content of each line with the comment prefix and
+	// surrounding whitespace removed.
+	Lines []string
+	// Indents holds the indentation of each line, relative to the least
+	// indented line of the batch, so that code in a description keeps its
+	// layout.
+	Indents []int
+}
+
+// add appends the content of a line, which starts at the 0-based column
+// col, to the batch.
+func (b *Batch) add(str string, col int) {
+	trimmed := strings.TrimLeft(str, " \t")
+	b.Cols = append(b.Cols, col+len(str)-len(trimmed)+1)
+	b.Lines = append(b.Lines, strings.TrimSpace(trimmed))
+	b.Indents = append(b.Indents, indentWidth(str[:len(str)-len(trimmed)]))
+}
+
+// dedent makes the Indents of the lines relative to the least indented
+// non-blank line. The first skip lines are taken to have no indentation.
+func (b *Batch) dedent(skip int) {
+	min := -1
+	for i := skip; i < len(b.Lines); i++ {
+		if b.Lines[i] != "" && (min == -1 || b.Indents[i] < min) {
+			min = b.Indents[i]
+		}
+	}
+	for i := range b.Indents {
+		if i < skip || b.Lines[i] == "" {
+			b.Indents[i] = 0
+		} else {
+			b.Indents[i] -= min
+		}
+	}
+}
+
+// indentWidth returns the width of the whitespace ws, with tab stops every
+// four columns.
+func indentWidth(ws string) int {
+	n := 0
+	for _, r := range ws {
+		if r == '\t' {
+			n += 4 - n%4
 		} else {
-			if found {
-				tmp := make([]string, len(b))
-				copy(tmp, b)
-				r = append(r, tmp)
-				b = nil
-				found = false
+			n++
+		}
+	}
+	return n
+}
+
+// TrimDsDoc extracts the DsDoc comment batches from the lines of the file
+// at path, using the comment syntax for its extension. It returns nil if the
+// extension has no known syntax.
+func TrimDsDoc(s []string, path string) []Batch {
+	syn, ok := Lookup(path)
+	if !ok {
+		return nil
+	}
+	return Trim(s, path, syn)
+}
+
+// Trim extracts the DsDoc comment batches written in syn from the lines of
+// the file at path. Each block comment is a batch of its own, as is each run
+// of consecutive line comments. Markers within literals and regular comments
+// are ignored.
+func Trim(s []string, path string, syn Syntax) []Batch {
+	if syn.Separator != "" {
+		return split(s, path, syn.Separator)
+	}
+
+	var r []Batch
+	var b Batch
+
+	lex := &lexer{syn: syn}
+	var found, block bool
+	// skip is the number of lines of the batch to leave out of dedent, as
+	// content following the opening of a block is not aligned with the rest.
+	var skip int
+	flush := func() {
+		if found && len(b.Lines) > 0 {
+			b.dedent(skip)
+			r = append(r, b)
+		}
+		found, block, skip = false, false, 0
+	}
+	start := func(i int) {
+		if !found {
+			b = Batch{File: path, Line: i + 1}
+		}
+		found = true
+	}
+
+	for i, str := range s {
+		if block {
+			col := 0
+			if t := strings.TrimLeft(str, " \t"); strings.HasPrefix(t, "*") && !strings.HasPrefix(t, syn.End) {
+				col = len(str) - len(t) + 1
 			}
+			if j := strings.Index(str[col:], syn.End); j != -1 {
+				if c := str[col : col+j]; strings.TrimSpace(c) != "" {
+					b.add(c, col)
+				}
+				flush()
+			} else {
+				b.add(str[col:], col)
+			}
+			continue
+		}
+
+		switch m, j := lex.next(str); m {
+		case blockMarker:
+			flush()
+			start(i)
+			col := j + len(syn.Start)
+			rest := str[col:]
+			if e := strings.Index(rest, syn.End); e != -1 {
+				if strings.TrimSpace(rest[:e]) != "" {
+					b.add(rest[:e], col)
+				}
+				flush()
+				continue
+			}
+			block = true
+			if strings.TrimSpace(rest) != "" {
+				b.add(rest, col)
+				skip = 1
+			} else {
+				// The content starts on the next line.
+				b.Line++
+			}
+		case lineMarker:
+			start(i)
+			b.add(str[j+len(syn.Line):], j+len(syn.Line))
+		default:
+			flush()
 		}
 	}
-	if len(b) > 0 {
-		tmp := make([]string, len(b))
-		copy(tmp, b)
-		r = append(r, tmp)
+	flush()
+
+	return r
+}
+
+// split returns the batches of a file containing only DsDoc syntax, which are
+// separated by lines equal to sep. Blank lines around each batch are dropped.
+func split(s []string, path, sep string) []Batch {
+	var r []Batch
+	b := Batch{File: path, Line: 1}
+	flush := func() {
+		for len(b.Lines) > 0 && b.Lines[len(b.Lines)-1] == "" {
+			b.Lines = b.Lines[:len(b.Lines)-1]
+			b.Cols = b.Cols[:len(b.Cols)-1]
+			b.Indents = b.Indents[:len(b.Indents)-1]
+		}
+		if len(b.Lines) > 0 {
+			b.dedent(0)
+			r = append(r, b)
+		}
+	}
+
+	for i, str := range s {
+		if strings.TrimSpace(str) == sep {
+			flush()
+			b = Batch{File: path, Line: i + 2}
+			continue
+		}
+		if len(b.Lines) == 0 && strings.TrimSpace(str) == "" {
+			b.Line = i + 2
+			continue
+		}
+		b.add(str, 0)
 	}
+	flush()
 
 	return r
 }
@@ -1,6 +1,8 @@
 package trim
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -74,21 +76,250 @@ func TestTrimDsDoc(t *testing.T) {
 	}
 
 	for i, tt := range tests {
-		res := TrimDsDoc(tt.in)
+		res := TrimDsDoc(tt.in, "test.go")
 
 		if len(res) != len(tt.out) {
 			t.Fatalf("%d. Batch counts do not match: exp=%d got=%d", i, len(tt.out), len(res))
 		}
 
 		for j, bt := range tt.out {
-			if len(bt) != len(res[j]) {
-				t.Errorf("%d. %d. Line counts do not match: exp=%d got=%d", i, j, len(bt), len(res[j]))
+			if len(bt) != len(res[j].Lines) {
+				t.Errorf("%d. %d. Line counts do not match: exp=%d got=%d", i, j, len(bt), len(res[j].Lines))
 			}
 			for k, str := range bt {
-				if str != res[j][k] {
-					t.Errorf("%d. %q does not match %q", i, str, res[j][k])
+				if str != res[j].Lines[k] {
+					t.Errorf("%d. %q does not match %q", i, str, res[j].Lines[k])
 				}
 			}
 		}
 	}
 }
+
+// Ensure batches report where they were found in the source file.
+func TestTrimDsDoc_Position(t *testing.T) {
+	in := []string{
+		`package main`,
+		``,
+		`//* @Node version`,
+		`x := 1 //*   @Parent root`,
+		`//*`,
+		`func main() {}`,
+		"\t//* @Node other",
+	}
+	exp := []Batch{
+		{File: "lib/main.go", Line: 3, Cols: []int{5, 14, 4}},
+		{File: "lib/main.go", Line: 7, Cols: []int{6}},
+	}
+
+	res := TrimDsDoc(in, "lib/main.go")
+	if len(res) != len(exp) {
+		t.Fatalf("Batch counts do not match: exp=%d got=%d", len(exp), len(res))
+	}
+	for i, b := range exp {
+		if b.File != res[i].File {
+			t.Errorf("%d. File mismatch: exp=%q got=%q", i, b.File, res[i].File)
+		}
+		if b.Line != res[i].Line {
+			t.Errorf("%d. Line mismatch: exp=%d got=%d", i, b.Line, res[i].Line)
+		}
+		if len(b.Cols) != len(res[i].Cols) {
+			t.Fatalf("%d. Column counts do not match: exp=%d got=%d", i, len(b.Cols), len(res[i].Cols))
+		}
+		for j, c := range b.Cols {
+			if c != res[i].Cols[j] {
+				t.Errorf("%d. %d. Column mismatch: exp=%d got=%d", i, j, c, res[i].Cols[j])
+			}
+		}
+	}
+}
+
+// Ensure each language's comment syntax is recognised.
+func TestTrimDsDoc_Languages(t *testing.T) {
+	var tests = []struct {
+		path string
+		in   []string
+		out  [][]string
+		line []int
+	}{
+		{
+			path: "lib/device.dart",
+			in: []string{
+				`/***`,
+				` * @Node version`,
+				` * @Parent root`,
+				` *`,
+				` * The *version*.`,
+				` */`,
+				`/** Not a DsDoc. */`,
+				`/********************/`,
+				`/***/`,
+			},
+			out:  [][]string{{`@Node version`, `@Parent root`, ``, `The *version*.`}},
+			line: []int{2},
+		},
+		{
+			path: "src/Device.java",
+			in: []string{
+				`/*** @Action Reset`,
+				`     @Parent root */`,
+				`class Device {}`,
+				`/*** @Node name */ //* @Node other`,
+				`//* @Node last`,
+			},
+			out: [][]string{
+				{`@Action Reset`, `@Parent root`},
+				{`@Node name`},
+				{`@Node last`},
+			},
+			line: []int{1, 4, 5},
+		},
+		{
+			path: "device.py",
+			in: []string{
+				`#* @Node version`,
+				`version = 1  #* @Parent root`,
+				`# A comment`,
+				`//* @Node ignored`,
+			},
+			out:  [][]string{{`@Node version`, `@Parent root`}},
+			line: []int{1},
+		},
+		{
+			path: "device.RB",
+			in:   []string{`#* @Node version`},
+			out:  [][]string{{`@Node version`}},
+			line: []int{1},
+		},
+		{
+			path: "run.sh",
+			in:   []string{`#!/bin/sh`, `#* @Action Run`},
+			out:  [][]string{{`@Action Run`}},
+			line: []int{2},
+		},
+		{
+			path: "link.yaml",
+			in:   []string{`name: link`, `#* @Node config`},
+			out:  [][]string{{`@Node config`}},
+			line: []int{2},
+		},
+		{
+			path: "device.lua",
+			in:   []string{`--* @Node version`, `--*`, `-- A comment`},
+			out:  [][]string{{`@Node version`, ``}},
+			line: []int{1},
+		},
+		{
+			path: "schema.sql",
+			in:   []string{`SELECT 1; --* @Action Query`},
+			out:  [][]string{{`@Action Query`}},
+			line: []int{1},
+		},
+		{
+			path: "README.md",
+			in:   []string{`//* @Node version`},
+		},
+	}
+
+	for _, tt := range tests {
+		res := TrimDsDoc(tt.in, tt.path)
+		if len(res) != len(tt.out) {
+			t.Fatalf("%s. Batch counts do not match: exp=%d got=%d", tt.path, len(tt.out), len(res))
+		}
+		for j, bt := range tt.out {
+			if res[j].Line != tt.line[j] {
+				t.Errorf("%s. %d. Line mismatch: exp=%d got=%d", tt.path, j, tt.line[j], res[j].Line)
+			}
+			if strings.Join(bt, "\n") != strings.Join(res[j].Lines, "\n") {
+				t.Errorf("%s. %d. Lines mismatch: exp=%q got=%q", tt.path, j, bt, res[j].Lines)
+			}
+		}
+	}
+}
+
+// Ensure columns within block comments skip the decoration.
+func TestTrimDsDoc_BlockPosition(t *testing.T) {
+	in := []string{
+		`/*** @Node version`,
+		`  * @Parent root`,
+		`    Short description. */`,
+	}
+	exp := []int{6, 5, 5}
+
+	res := TrimDsDoc(in, "main.go")
+	if len(res) != 1 {
+		t.Fatalf("Batch counts do not match: exp=1 got=%d", len(res))
+	}
+	for i, c := range exp {
+		if c != res[0].Cols[i] {
+			t.Errorf("%d. Column mismatch: exp=%d got=%d", i, c, res[0].Cols[i])
+		}
+	}
+}
+
+// Ensure .dsdoc files are split into batches on --- lines.
+func TestTrimDsDoc_Standalone(t *testing.T) {
+	in := []string{
+		``,
+		`@Node config`,
+		`@Parent root`,
+		``,
+		`  Loaded from configuration.`,
+		``,
+		`---`,
+		``,
+		`---`,
+		`@Action Reload`,
+		`@Parent config`,
+		`--- `,
+	}
+	var exp = []Batch{
+		{File: "nodes.dsdoc", Line: 2, Cols: []int{1, 1, 1, 3}, Lines: []string{`@Node config`, `@Parent root`, ``, `Loaded from configuration.`}},
+		{File: "nodes.dsdoc", Line: 10, Cols: []int{1, 1}, Lines: []string{`@Action Reload`, `@Parent config`}},
+	}
+
+	res := TrimDsDoc(in, "nodes.dsdoc")
+	if len(res) != len(exp) {
+		t.Fatalf("Batch counts do not match: exp=%d got=%d", len(exp), len(res))
+	}
+	for i, b := range exp {
+		if b.File != res[i].File || b.Line != res[i].Line {
+			t.Errorf("%d. Position mismatch: exp=%s:%d got=%s:%d", i, b.File, b.Line, res[i].File, res[i].Line)
+		}
+		if strings.Join(b.Lines, "\n") != strings.Join(res[i].Lines, "\n") {
+			t.Errorf("%d. Lines mismatch: exp=%q got=%q", i, b.Lines, res[i].Lines)
+		}
+		if len(b.Cols) != len(res[i].Cols) {
+			t.Fatalf("%d. Column counts do not match: exp=%d got=%d", i, len(b.Cols), len(res[i].Cols))
+		}
+		for j, c := range b.Cols {
+			if c != res[i].Cols[j] {
+				t.Errorf("%d. %d. Column mismatch: exp=%d got=%d", i, j, c, res[i].Cols[j])
+			}
+		}
+	}
+}
+
+// Ensure the indentation of each line is kept relative to the batch.
+func TestTrimDsDoc_Indents(t *testing.T) {
+	var tests = []struct {
+		path string
+		in   []string
+		exp  [][]int
+	}{
+		{"a.dart", []string{`//* @Node a`, `//*`, `//*     code`, "//*\tcode"}, [][]int{{0, 0, 4, 3}}},
+		{"a.dart", []string{`  //*@Node a`, `//*  text`}, [][]int{{0, 2}}},
+		{"a.java", []string{`/***`, ` * @Node a`, ` *`, ` *     code`, ` */`}, [][]int{{0, 0, 4}}},
+		{"a.java", []string{`/*** @Node a`, `      @Parent root`, `          code`, `*/`}, [][]int{{0, 0, 4}}},
+		{"a.dsdoc", []string{`@Node a`, ``, `    code`, `---`, `  @Node b`, `  text`}, [][]int{{0, 0, 4}, {0, 0}}},
+	}
+	for i, tt := range tests {
+		res := TrimDsDoc(tt.in, tt.path)
+		var got [][]int
+		for _, b := range res {
+			got = append(got, b.Indents)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.exp) {
+			t.Errorf("%d. Indents mismatch: exp=%v got=%v", i, tt.exp, got)
+		}
+	}
+}
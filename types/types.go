@@ -0,0 +1,188 @@
+// Package types implements the DSA value types which may be declared on
+// action parameters, result columns and node values.
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind represents the base DSA type.
+type Kind int
+
+const (
+	// None indicates no type was declared.
+	None Kind = iota
+	// String is a string value.
+	String
+	// Number is a numeric value.
+	Number
+	// Bool is a boolean value.
+	Bool
+	// Int is an integer value.
+	Int
+	// Map is a map of key value pairs.
+	Map
+	// Array is a list of values.
+	Array
+	// Dynamic is a value of any type.
+	Dynamic
+	// Binary is binary data.
+	Binary
+	// Enum is one of a fixed set of string options.
+	Enum
+	// Time is a date and time value.
+	Time
+)
+
+var kinds = map[string]Kind{
+	"string":  String,
+	"number":  Number,
+	"num":     Number,
+	"bool":    Bool,
+	"int":     Int,
+	"map":     Map,
+	"array":   Array,
+	"dynamic": Dynamic,
+	"binary":  Binary,
+	"enum":    Enum,
+	"time":    Time,
+}
+
+func (k Kind) String() string {
+	switch k {
+	case String:
+		return "string"
+	case Number:
+		return "number"
+	case Bool:
+		return "bool"
+	case Int:
+		return "int"
+	case Map:
+		return "map"
+	case Array:
+		return "array"
+	case Dynamic:
+		return "dynamic"
+	case Binary:
+		return "binary"
+	case Enum:
+		return "enum"
+	case Time:
+		return "time"
+	}
+	return ""
+}
+
+// Type is a DSA type.
+type Type struct {
+	Kind Kind
+	// Options holds the allowed values of an Enum type.
+	Options []string
+}
+
+// Parse returns the Type represented by s. Type names are not case
+// sensitive, and enum options are listed within square brackets, eg
+// enum[on,off]. Parse returns an error for unknown types and malformed
+// enums.
+func Parse(s string) (Type, error) {
+	name, opts := s, ""
+	hasOpts := false
+	if i := strings.IndexByte(s, '['); i != -1 {
+		if !strings.HasSuffix(s, "]") {
+			return Type{}, fmt.Errorf("type %q is missing closing bracket ']'", s)
+		}
+		name, opts, hasOpts = s[:i], s[i+1:len(s)-1], true
+	}
+
+	k, ok := kinds[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Type{}, fmt.Errorf("unknown type %q", name)
+	}
+	t := Type{Kind: k}
+	if k != Enum {
+		if hasOpts {
+			return Type{}, fmt.Errorf("type %q does not accept options", name)
+		}
+		return t, nil
+	}
+
+	if !hasOpts {
+		return Type{}, fmt.Errorf("enum requires a list of options, eg enum[on,off]")
+	}
+	seen := make(map[string]bool)
+	for _, o := range strings.Split(opts, ",") {
+		o = strings.TrimSpace(o)
+		if o == "" {
+			return Type{}, fmt.Errorf("enum %q contains an empty option", s)
+		}
+		if seen[o] {
+			return Type{}, fmt.Errorf("enum %q contains duplicate option %q", s, o)
+		}
+		seen[o] = true
+		t.Options = append(t.Options, o)
+	}
+	return t, nil
+}
+
+// String returns the type in the form accepted by Parse.
+func (t Type) String() string {
+	if t.Kind == Enum {
+		return fmt.Sprintf("enum[%s]", strings.Join(t.Options, ","))
+	}
+	return t.Kind.String()
+}
+
+// Equal reports whether t and o are the same type.
+func (t Type) Equal(o Type) bool {
+	if t.Kind != o.Kind || len(t.Options) != len(o.Options) {
+		return false
+	}
+	for i := range t.Options {
+		if t.Options[i] != o.Options[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Check returns an error if the literal value v is not a value of type t.
+// Numbers and ints are written as in Go, bools as true or false, times in
+// RFC 3339 form, and maps and arrays as JSON objects and arrays. Any value is
+// accepted for the other kinds.
+func (t Type) Check(v string) error {
+	var err error
+	switch t.Kind {
+	case Number:
+		_, err = strconv.ParseFloat(v, 64)
+	case Int:
+		_, err = strconv.ParseInt(v, 10, 64)
+	case Bool:
+		if v != "true" && v != "false" {
+			err = fmt.Errorf("expected true or false")
+		}
+	case Enum:
+		for _, o := range t.Options {
+			if v == o {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not one of %s", v, strings.Join(t.Options, ", "))
+	case Time:
+		_, err = time.Parse(time.RFC3339, v)
+	case Map:
+		var m map[string]interface{}
+		err = json.Unmarshal([]byte(v), &m)
+	case Array:
+		var a []interface{}
+		err = json.Unmarshal([]byte(v), &a)
+	}
+	if err != nil {
+		return fmt.Errorf("%q is not a valid %s", v, t.Kind)
+	}
+	return nil
+}
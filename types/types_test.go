@@ -0,0 +1,94 @@
+package types
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		s   string
+		typ Type
+		str string
+		err string
+	}{
+		{s: "string", typ: Type{Kind: String}, str: "string"},
+		{s: "number", typ: Type{Kind: Number}, str: "number"},
+		{s: "num", typ: Type{Kind: Number}, str: "number"},
+		{s: "bool", typ: Type{Kind: Bool}, str: "bool"},
+		{s: "int", typ: Type{Kind: Int}, str: "int"},
+		{s: "map", typ: Type{Kind: Map}, str: "map"},
+		{s: "array", typ: Type{Kind: Array}, str: "array"},
+		{s: "dynamic", typ: Type{Kind: Dynamic}, str: "dynamic"},
+		{s: "binary", typ: Type{Kind: Binary}, str: "binary"},
+		{s: "time", typ: Type{Kind: Time}, str: "time"},
+		{s: "String", typ: Type{Kind: String}, str: "string"},
+		{s: "enum[on,off]", typ: Type{Kind: Enum, Options: []string{"on", "off"}}, str: "enum[on,off]"},
+		{s: "enum[Low, Medium, High]", typ: Type{Kind: Enum, Options: []string{"Low", "Medium", "High"}}, str: "enum[Low,Medium,High]"},
+		{s: "strng", err: `unknown type "strng"`},
+		{s: "enum", err: "enum requires a list of options, eg enum[on,off]"},
+		{s: "enum[a,,b]", err: `enum "enum[a,,b]" contains an empty option`},
+		{s: "enum[]", err: `enum "enum[]" contains an empty option`},
+		{s: "enum[a,b,a]", err: `enum "enum[a,b,a]" contains duplicate option "a"`},
+		{s: "enum[a,b", err: `type "enum[a,b" is missing closing bracket ']'`},
+		{s: "string[a]", err: `type "string" does not accept options`},
+	}
+
+	for i, tt := range tests {
+		typ, err := Parse(tt.s)
+		var es string
+		if err != nil {
+			es = err.Error()
+		}
+		if es != tt.err {
+			t.Errorf("%d. %q error mismatch:\n  exp=%q\n  got=%q", i, tt.s, tt.err, es)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !typ.Equal(tt.typ) {
+			t.Errorf("%d. %q type mismatch: exp=%#v got=%#v", i, tt.s, tt.typ, typ)
+		}
+		if typ.String() != tt.str {
+			t.Errorf("%d. %q string mismatch: exp=%q got=%q", i, tt.s, tt.str, typ.String())
+		}
+	}
+}
+
+func TestType_Check(t *testing.T) {
+	var tests = []struct {
+		typ string
+		v   string
+		err string
+	}{
+		{typ: "string", v: "anything"},
+		{typ: "number", v: "1.5e3"},
+		{typ: "number", v: "one", err: `"one" is not a valid number`},
+		{typ: "int", v: "-42"},
+		{typ: "int", v: "4.2", err: `"4.2" is not a valid int`},
+		{typ: "bool", v: "false"},
+		{typ: "bool", v: "1", err: `"1" is not a valid bool`},
+		{typ: "enum[on,off]", v: "off"},
+		{typ: "enum[on,off]", v: "auto", err: `"auto" is not one of on, off`},
+		{typ: "time", v: "2020-01-02T15:04:05Z"},
+		{typ: "time", v: "2020-01-02", err: `"2020-01-02" is not a valid time`},
+		{typ: "map", v: `{"a": 1}`},
+		{typ: "map", v: `[1]`, err: `"[1]" is not a valid map`},
+		{typ: "array", v: `[1, "a"]`},
+		{typ: "dynamic", v: "{"},
+	}
+
+	for i, tt := range tests {
+		typ, err := Parse(tt.typ)
+		if err != nil {
+			t.Fatalf("%d. Unexpected parse error %q", i, err)
+		}
+		var es string
+		if err := typ.Check(tt.v); err != nil {
+			es = err.Error()
+		}
+		if es != tt.err {
+			t.Errorf("%d. %s %q error mismatch:\n  exp=%q\n  got=%q", i, tt.typ, tt.v, tt.err, es)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/butlermatt/dsdoc/ignore"
+)
+
+// ignoreFile is the name of the file listing paths which dsdoc skips.
+const ignoreFile = ".dsdocignore"
+
+// globs is a repeatable flag holding glob patterns.
+type globs []string
+
+func (g *globs) String() string { return strings.Join(*g, ",") }
+
+func (g *globs) Set(s string) error {
+	if _, err := ignore.Compile(s); err != nil {
+		return err
+	}
+	*g = append(*g, s)
+	return nil
+}
+
+// walker finds the files beneath the input paths.
+type walker struct {
+	// includes, if set, limits the files found to those it matches.
+	includes *ignore.Matcher
+	// excludes matches the files and directories to skip.
+	excludes *ignore.Matcher
+	// gitignore is true if .gitignore files are honoured as well as
+	// .dsdocignore files.
+	gitignore bool
+}
+
+func newWalker(include, exclude []string, gitignore bool) (*walker, error) {
+	w := &walker{gitignore: gitignore}
+	var err error
+	if len(include) > 0 {
+		if w.includes, err = ignore.New("", include); err != nil {
+			return nil, err
+		}
+	}
+	if w.excludes, err = ignore.New("", exclude); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// walk calls fn with the path of each regular file beneath the input path
+// in, and the name it is reported by. Names are slash separated and relative
+// to in, prefixed by in unless it is the working directory. A file named
+// directly is always passed to fn.
+func (w *walker) walk(in string, fn func(path, name string)) error {
+	info, err := os.Stat(in)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		fn(in, filepath.ToSlash(filepath.Clean(in)))
+		return nil
+	}
+
+	tree := ignore.NewTree(ignoreFile)
+	if w.gitignore {
+		tree = ignore.NewTree(".gitignore", ignoreFile)
+	}
+	tree.Add(w.excludes)
+
+	return filepath.Walk(in, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return nil
+		}
+		rel, err := filepath.Rel(in, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel != "." {
+			if strings.HasPrefix(info.Name(), ".") || tree.Ignored(rel, info.IsDir()) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		if info.IsDir() {
+			if err := tree.Load(path, rel); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if w.includes != nil {
+			if _, ok := w.includes.Match(rel, false); !ok {
+				return nil
+			}
+		}
+
+		fn(path, filepath.ToSlash(filepath.Join(in, rel)))
+		return nil
+	})
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/butlermatt/dsdoc/cache"
+	"github.com/butlermatt/dsdoc/parser"
+	"github.com/butlermatt/dsdoc/scan"
+)
+
+// fileState is what a poll records of a file to tell when it has changed.
+type fileState struct {
+	size    int64
+	modTime int64
+}
+
+// watcher regenerates the outputs of a project whenever its files change.
+type watcher struct {
+	p     *project
+	sc    *scan.Scanner
+	cache *cache.Cache
+	// states and frags hold the state and fragment of each file as of the
+	// last build.
+	states map[string]fileState
+	frags  map[string]*parser.Fragment
+}
+
+// watchCmd generates the documentation, then polls the inputs and generates
+// it again each time they change, until interrupted.
+func watchCmd(args []string) {
+	fs := flag.NewFlagSet("dsdoc watch", flag.ExitOnError)
+	interval := fs.Duration("interval", 500*time.Millisecond, "how often the inputs are checked for changes")
+	quiet := fs.Duration("debounce", 200*time.Millisecond, "how long the inputs must be unchanged before generating")
+	cfg, err := settings(fs, args)
+	if err == nil && checkOnly {
+		err = fmt.Errorf("-check cannot be used with watch")
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	p, err := newProject(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	var c *cache.Cache
+	if !noCache {
+		c = cache.Open(cachePath(cfg), toolVersion())
+	}
+	w := &watcher{
+		p:      p,
+		sc:     &scan.Scanner{Jobs: cfg.Jobs, Cache: c, Config: cfg.File},
+		cache:  c,
+		states: make(map[string]fileState),
+		frags:  make(map[string]*parser.Fragment),
+	}
+	if err := w.run(*interval, *quiet); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run builds once, then polls every interval. Once the files have changed
+// and then stayed the same for at least quiet, they are built again. A burst
+// of changes, such as a branch switch, results in a single build. It only
+// returns if the inputs cannot be read to begin with.
+func (w *watcher) run(interval, quiet time.Duration) error {
+	files, last, err := w.poll()
+	if err != nil {
+		return err
+	}
+	w.build(files, last)
+
+	var lastErr error
+	changed := time.Now()
+	for {
+		time.Sleep(interval)
+		files, states, err := w.poll()
+		if err != nil {
+			// Report a failing poll once rather than on every interval.
+			if lastErr == nil || err.Error() != lastErr.Error() {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			lastErr = err
+			continue
+		}
+		lastErr = nil
+
+		if !sameStates(states, last) {
+			last = states
+			changed = time.Now()
+			continue
+		}
+		if sameStates(states, w.states) || time.Since(changed) < quiet {
+			continue
+		}
+		w.build(files, states)
+	}
+}
+
+// poll walks the inputs and returns the files found and their states.
+func (w *watcher) poll() ([]scan.File, map[string]fileState, error) {
+	files, err := w.p.files()
+	if err != nil {
+		return nil, nil, err
+	}
+	states := make(map[string]fileState, len(files))
+	found := files[:0]
+	for _, f := range files {
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			// Removed since the walk, the next poll will not find it.
+			continue
+		}
+		states[f.Path] = fileState{size: info.Size(), modTime: info.ModTime().UnixNano()}
+		found = append(found, f)
+	}
+	return found, states, nil
+}
+
+// build scans the files which are new or have changed since the last build,
+// reusing the fragments of the others, then writes the outputs and reports
+// the result.
+func (w *watcher) build(files []scan.File, states map[string]fileState) {
+	changed, removed := changes(files, states, w.states)
+	scanned := w.sc.Scan(changed)
+
+	frags := make(map[string]*parser.Fragment, len(files))
+	for i, f := range changed {
+		frags[f.Path] = scanned[i]
+	}
+	ordered := make([]*parser.Fragment, len(files))
+	for i, f := range files {
+		fr, ok := frags[f.Path]
+		if !ok {
+			fr = w.frags[f.Path]
+			frags[f.Path] = fr
+		}
+		ordered[i] = fr
+	}
+	w.states, w.frags = states, frags
+
+	if w.cache != nil {
+		if err := w.cache.Save(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "%s %d file(s) scanned", time.Now().Format("15:04:05"), len(changed))
+	if len(removed) > 0 {
+		fmt.Fprintf(os.Stderr, ", %d removed", len(removed))
+	}
+	fmt.Fprintln(os.Stderr)
+	if err := w.p.write(ordered); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	var names []string
+	for i := range w.p.formats {
+		names = append(names, w.p.outputPath(i))
+	}
+	fmt.Fprintf(os.Stderr, "wrote %s\n", strings.Join(names, ", "))
+}
+
+// changes compares the files found by a poll and their states with the
+// states of the last build, last. It returns the files which have been added
+// or modified, in the order found, and the paths of the files which have
+// been removed, sorted.
+func changes(files []scan.File, states, last map[string]fileState) (changed []scan.File, removed []string) {
+	for _, f := range files {
+		if s, ok := last[f.Path]; !ok || s != states[f.Path] {
+			changed = append(changed, f)
+		}
+	}
+	for p := range last {
+		if _, ok := states[p]; !ok {
+			removed = append(removed, p)
+		}
+	}
+	sort.Strings(removed)
+	return changed, removed
+}
+
+// sameStates reports whether a and b hold the same files in the same states.
+func sameStates(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for p, s := range a {
+		if t, ok := b[p]; !ok || s != t {
+			return false
+		}
+	}
+	return true
+}
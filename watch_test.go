@@ -0,0 +1,109 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/butlermatt/dsdoc/parser"
+	"github.com/butlermatt/dsdoc/scan"
+)
+
+func TestChanges(t *testing.T) {
+	last := map[string]fileState{
+		"a.dart": {size: 1, modTime: 1},
+		"b.dart": {size: 2, modTime: 2},
+		"c.dart": {size: 3, modTime: 3},
+	}
+	files := []scan.File{{Path: "d.dart"}, {Path: "a.dart"}, {Path: "b.dart"}}
+	states := map[string]fileState{
+		"a.dart": {size: 1, modTime: 1},
+		"b.dart": {size: 2, modTime: 4},
+		"d.dart": {size: 5, modTime: 5},
+	}
+
+	changed, removed := changes(files, states, last)
+	if exp := []scan.File{{Path: "d.dart"}, {Path: "b.dart"}}; !reflect.DeepEqual(changed, exp) {
+		t.Errorf("Changed mismatch: exp=%v got=%v", exp, changed)
+	}
+	if exp := []string{"c.dart"}; !reflect.DeepEqual(removed, exp) {
+		t.Errorf("Removed mismatch: exp=%v got=%v", exp, removed)
+	}
+
+	if changed, removed := changes(files, states, states); changed != nil || removed != nil {
+		t.Errorf("Expected no changes, got %v %v", changed, removed)
+	}
+}
+
+func TestWatcher_Build(t *testing.T) {
+	cfg, done := testProject(t, map[string]string{"lib/device.dart": deviceSrc})
+	defer done()
+	dir := cfg.Inputs[0]
+	p, err := newProject(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &watcher{
+		p:      p,
+		sc:     &scan.Scanner{Jobs: 1},
+		states: make(map[string]fileState),
+		frags:  make(map[string]*parser.Fragment),
+	}
+
+	// build polls the files, checks their changes against exp, builds them
+	// and returns the output.
+	build := func(exp []string, removed int) string {
+		t.Helper()
+		files, states, err := w.poll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		changed, gone := changes(files, states, w.states)
+		var got []string
+		for _, f := range changed {
+			got = append(got, filepath.Base(f.Path))
+		}
+		if !reflect.DeepEqual(got, exp) || len(gone) != removed {
+			t.Errorf("Changes mismatch: exp=%v and %d removed, got=%v and %v", exp, removed, got, gone)
+		}
+		w.build(files, states)
+		b, err := ioutil.ReadFile(cfg.Outputs[0].Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	if out := build([]string{"device.dart"}, 0); !strings.Contains(out, "### device") {
+		t.Errorf("Expected the device in:\n%s", out)
+	}
+
+	// An added file is scanned, and the unchanged file is reused.
+	action := filepath.Join(dir, "lib", "reset.dart")
+	writeTestFile(t, action, "//* @Action Reset\n//* @Parent device\n//*\n//* Resets.\n")
+	if out := build([]string{"reset.dart"}, 0); !strings.Contains(out, "### device") || !strings.Contains(out, "### Reset") {
+		t.Errorf("Expected the device and action in:\n%s", out)
+	}
+
+	// A modified file is scanned again.
+	writeTestFile(t, action, "//* @Action Restart\n//* @Parent device\n//*\n//* Restarts.\n")
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(action, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if out := build([]string{"reset.dart"}, 0); strings.Contains(out, "### Reset") || !strings.Contains(out, "### Restart") {
+		t.Errorf("Expected the renamed action in:\n%s", out)
+	}
+
+	// A removed file is dropped from the output.
+	if err := os.Remove(action); err != nil {
+		t.Fatal(err)
+	}
+	if out := build(nil, 1); strings.Contains(out, "### Restart") || !strings.Contains(out, "### device") {
+		t.Errorf("Expected only the device in:\n%s", out)
+	}
+}